@@ -12,12 +12,18 @@ import (
 type (
 	// ProbeConfig is a struct for passing configuration data to a new Probe.
 	ProbeConfig struct {
-		LogHandler slog.Handler    // Handler to use for probe logging. If empty, probe.NoopHandler will be used.
-		Ctx        context.Context // Context to use for the probe. If empty, context.Background will be used.
-		WorkChan   chan Runner     // Channel to use for work. If empty, a new channel will be created.
-		RunningCtr *atomic.Int32   // Running counter to increment when this probe is running.
-		IdleCtr    *atomic.Int32   // Idle counter to increment when this probe is idle.
-		WaitGroup  *sync.WaitGroup // WaitGroup to use for the probe.
+		Name        string          // Name of the probe, used in logs and the registry. Optional.
+		Register    bool            // Add the probe to the process-wide registry while it is running. Requires Name.
+		IDGenerator IDGenerator     // Generator of the probe ID. If empty, a process-wide SequenceIDGenerator will be used.
+		LogHandler  slog.Handler    // Handler to use for probe logging. If empty, probe.NoopHandler will be used.
+		Ctx         context.Context // Context to use for the probe. If empty, context.Background will be used.
+		WorkChan    chan Runner     // Channel to use for work. If empty, a new channel will be created.
+		Queue       Queue           // Queue to use for work. If set, WorkChan is ignored.
+		RunningCtr  *atomic.Int32   // Running counter to increment when this probe is running.
+		IdleCtr     *atomic.Int32   // Idle counter to increment when this probe is idle.
+		WaitGroup   *sync.WaitGroup // WaitGroup to use for the probe.
+		OnStart     func()          // Called on the event loop goroutine every time the event loop starts. Optional.
+		OnStop      func()          // Called on the event loop goroutine every time the event loop exits. Optional.
 	}
 )
 
@@ -29,6 +35,14 @@ func (c *ProbeConfig) getLogHandler() slog.Handler {
 	return c.LogHandler
 }
 
+// getIDGenerator returns the ID generator to use for the Probe.
+func (c *ProbeConfig) getIDGenerator() IDGenerator {
+	if c.IDGenerator == nil {
+		return defaultIDGenerator
+	}
+	return c.IDGenerator
+}
+
 // getCtx returns the context to use for the Probe.
 func (c *ProbeConfig) getCtx() context.Context {
 	if c.Ctx == nil {
@@ -45,6 +59,14 @@ func (c *ProbeConfig) getWorkChan() chan Runner {
 	return c.WorkChan
 }
 
+// getQueue returns the Queue to use for the Probe.
+func (c *ProbeConfig) getQueue() Queue {
+	if c.Queue == nil {
+		return ChanQueue(c.getWorkChan())
+	}
+	return c.Queue
+}
+
 // getRunningCtr returns the running counter to use for the Probe.
 func (c *ProbeConfig) getRunningCtr() *atomic.Int32 {
 	if c.RunningCtr == nil {
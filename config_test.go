@@ -195,3 +195,55 @@ func TestProbeConfig_getWaitGroup(t *testing.T) {
 		}
 	}
 }
+
+func TestProbeConfig_getQueue(t *testing.T) {
+	cases := []struct {
+		q   Queue
+		msg string
+	}{
+		{
+			q:   make(ChanQueue),
+			msg: "getQueue(q) -> q",
+		},
+		{
+			q:   nil,
+			msg: "getQueue(nil) -> ChanQueue",
+		},
+	}
+	for _, c := range cases {
+		cfg := &ProbeConfig{
+			Queue: c.q,
+		}
+		if c.q != nil {
+			assert.Equal(t, c.q, cfg.getQueue(), c.msg)
+		} else {
+			assert.IsType(t, ChanQueue(nil), cfg.getQueue(), c.msg)
+		}
+	}
+}
+
+func TestProbeConfig_getIDGenerator(t *testing.T) {
+	cases := []struct {
+		g   IDGenerator
+		msg string
+	}{
+		{
+			g:   NewSequenceIDGenerator("test"),
+			msg: "getIDGenerator(g) -> g",
+		},
+		{
+			g:   nil,
+			msg: "getIDGenerator(nil) -> defaultIDGenerator",
+		},
+	}
+	for _, c := range cases {
+		cfg := &ProbeConfig{
+			IDGenerator: c.g,
+		}
+		if c.g != nil {
+			assert.Equal(t, c.g, cfg.getIDGenerator(), c.msg)
+		} else {
+			assert.Equal(t, defaultIDGenerator, cfg.getIDGenerator(), c.msg)
+		}
+	}
+}
@@ -0,0 +1,269 @@
+package debug
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"html/template"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/amplify-security/probe"
+	"github.com/amplify-security/probe/pool"
+)
+
+type (
+	// Handler is an http.Handler that renders the state of Pools and Probes. Handler renders HTML by default,
+	// and JSON if the request has the query parameter format=json or accepts application/json. The recent
+	// failures and panics of a Pool only cover Tasks, as reported by Pool.Failures. Runners submitted with
+	// Run are not recovered, so a panicking Runner crashes the process and is never rendered.
+	Handler struct {
+		mu         sync.Mutex
+		pools      []namedPool
+		probes     []namedProbe
+		registered bool
+	}
+
+	// namedPool is a Pool added to a Handler.
+	namedPool struct {
+		name string
+		pool *pool.Pool
+	}
+
+	// namedProbe is a Probe added to a Handler.
+	namedProbe struct {
+		name  string
+		probe *probe.Probe
+	}
+
+	// Snapshot is the state of all Pools and Probes of a Handler.
+	Snapshot struct {
+		Pools  []PoolSnapshot  `json:"pools"`
+		Probes []ProbeSnapshot `json:"probes"`
+	}
+
+	// PoolSnapshot is the state of a Pool.
+	PoolSnapshot struct {
+		Name     string             `json:"name"`
+		State    string             `json:"state"`
+		Paused   bool               `json:"paused"`
+		Size     int                `json:"size"`
+		Running  int                `json:"running"`
+		Idle     int                `json:"idle"`
+		Queued   int                `json:"queued"`
+		Expired  int                `json:"expired"`
+		InFlight []InFlightSnapshot `json:"in_flight"`
+		Failures []FailureSnapshot  `json:"failures"`
+	}
+
+	// ProbeSnapshot is the state of a Probe.
+	ProbeSnapshot struct {
+		Name     string            `json:"name"`
+		ID       string            `json:"id"`
+		State    string            `json:"state"`
+		Paused   bool              `json:"paused"`
+		Idle     bool              `json:"idle"`
+		Queued   int               `json:"queued"`
+		InFlight *InFlightSnapshot `json:"in_flight"`
+	}
+
+	// InFlightSnapshot is work executing on a Probe.
+	InFlightSnapshot struct {
+		ProbeID string            `json:"probe_id"`
+		Name    string            `json:"name"`
+		Labels  map[string]string `json:"labels"`
+		Started time.Time         `json:"started"`
+		Elapsed time.Duration     `json:"elapsed_ns"`
+	}
+
+	// FailureSnapshot is a Task that failed with an error or panicked. Runners are not included.
+	FailureSnapshot struct {
+		TaskID uint64            `json:"task_id"`
+		Name   string            `json:"name"`
+		Labels map[string]string `json:"labels"`
+		Error  string            `json:"error"`
+		Panic  bool              `json:"panic"`
+		Stack  string            `json:"stack,omitempty"`
+		Time   time.Time         `json:"time"`
+	}
+)
+
+var (
+	page = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html>
+<head><title>probe</title></head>
+<body>
+<h1>Pools</h1>
+{{range .Pools}}
+<h2>{{.Name}}</h2>
+<p>state: {{.State}}{{if .Paused}} (paused){{end}}, size: {{.Size}}, running: {{.Running}}, idle: {{.Idle}}, queued: {{.Queued}}, expired: {{.Expired}}</p>
+<h3>In flight</h3>
+<table>
+<tr><th>probe</th><th>name</th><th>labels</th><th>started</th><th>elapsed</th></tr>
+{{range .InFlight}}<tr><td>{{.ProbeID}}</td><td>{{.Name}}</td><td>{{.Labels}}</td><td>{{.Started}}</td><td>{{.Elapsed}}</td></tr>
+{{end}}</table>
+<h3>Recent failures</h3>
+<table>
+<tr><th>task</th><th>name</th><th>labels</th><th>time</th><th>error</th></tr>
+{{range .Failures}}<tr><td>{{.TaskID}}</td><td>{{.Name}}</td><td>{{.Labels}}</td><td>{{.Time}}</td><td>{{.Error}}{{if .Panic}}<pre>{{.Stack}}</pre>{{end}}</td></tr>
+{{end}}</table>
+{{end}}
+<h1>Probes</h1>
+<table>
+<tr><th>name</th><th>id</th><th>state</th><th>idle</th><th>queued</th><th>in flight</th><th>elapsed</th></tr>
+{{range .Probes}}<tr><td>{{.Name}}</td><td>{{.ID}}</td><td>{{.State}}{{if .Paused}} (paused){{end}}</td><td>{{.Idle}}</td><td>{{.Queued}}</td>{{with .InFlight}}<td>{{.Name}} {{.Labels}}</td><td>{{.Elapsed}}</td>{{else}}<td></td><td></td>{{end}}</tr>
+{{end}}</table>
+</body>
+</html>
+`))
+)
+
+// NewHandler initializes and returns a new Handler.
+func NewHandler() *Handler {
+	return &Handler{}
+}
+
+// NewRegistryHandler initializes and returns a new Handler that renders all Pools and Probes in the
+// process-wide registries, in addition to those added to it.
+func NewRegistryHandler() *Handler {
+	return &Handler{registered: true}
+}
+
+// AddPool adds a Pool to the Handler under name.
+func (h *Handler) AddPool(name string, p *pool.Pool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.pools = append(h.pools, namedPool{name: name, pool: p})
+}
+
+// AddProbe adds a Probe to the Handler under name.
+func (h *Handler) AddProbe(name string, p *probe.Probe) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.probes = append(h.probes, namedProbe{name: name, probe: p})
+}
+
+// Snapshot returns the current state of all Pools and Probes of the Handler.
+func (h *Handler) Snapshot() Snapshot {
+	h.mu.Lock()
+	pools, probes := h.pools, h.probes
+	h.mu.Unlock()
+	if h.registered {
+		// clip the slices so appending never writes to the arrays shared with h
+		pools, probes = slices.Clip(pools), slices.Clip(probes)
+		for _, p := range pool.Registered() {
+			pools = append(pools, namedPool{name: p.Name(), pool: p})
+		}
+		for _, p := range probe.Registered() {
+			probes = append(probes, namedProbe{name: p.Name(), probe: p})
+		}
+	}
+	s := Snapshot{
+		Pools:  make([]PoolSnapshot, 0, len(pools)),
+		Probes: make([]ProbeSnapshot, 0, len(probes)),
+	}
+	for _, p := range pools {
+		s.Pools = append(s.Pools, poolSnapshot(p.name, p.pool))
+	}
+	for _, p := range probes {
+		s.Probes = append(s.Probes, probeSnapshot(p.name, p.probe))
+	}
+	return s
+}
+
+// ServeHTTP implementation of http.Handler for Handler. The snapshot is rendered before anything is
+// written, so a rendering error is returned as 500 Internal Server Error instead of a truncated page.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s := h.Snapshot()
+	var (
+		b           bytes.Buffer
+		contentType string
+		err         error
+	)
+	if r.URL.Query().Get("format") == "json" || strings.Contains(r.Header.Get("Accept"), "application/json") {
+		contentType = "application/json"
+		err = json.NewEncoder(&b).Encode(s)
+	} else {
+		contentType = "text/html; charset=utf-8"
+		err = page.Execute(&b, s)
+	}
+	if err != nil {
+		http.Error(w, "debug: failed to render snapshot: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	// a failed write means the client went away, there is no one left to report it to
+	w.Write(b.Bytes())
+}
+
+// poolSnapshot returns the state of p.
+func poolSnapshot(name string, p *pool.Pool) PoolSnapshot {
+	stats := p.Stats()
+	s := PoolSnapshot{
+		Name:     name,
+		State:    p.State().String(),
+		Paused:   p.Paused(),
+		Size:     stats.Size,
+		Running:  stats.Running,
+		Idle:     stats.Idle,
+		Queued:   stats.Queued,
+		Expired:  stats.Expired,
+		InFlight: []InFlightSnapshot{},
+		Failures: []FailureSnapshot{},
+	}
+	for _, f := range p.InFlight() {
+		s.InFlight = append(s.InFlight, inFlightSnapshot(f))
+	}
+	for _, f := range p.Failures() {
+		s.Failures = append(s.Failures, failureSnapshot(f))
+	}
+	return s
+}
+
+// probeSnapshot returns the state of p.
+func probeSnapshot(name string, p *probe.Probe) ProbeSnapshot {
+	s := ProbeSnapshot{
+		Name:   name,
+		ID:     p.ID(),
+		State:  p.State().String(),
+		Paused: p.Paused(),
+		Idle:   p.Idle(),
+		Queued: p.Queue().Len(),
+	}
+	if f, ok := p.InFlight(); ok {
+		inFlight := inFlightSnapshot(f)
+		s.InFlight = &inFlight
+	}
+	return s
+}
+
+// inFlightSnapshot returns the state of f.
+func inFlightSnapshot(f probe.InFlight) InFlightSnapshot {
+	return InFlightSnapshot{
+		ProbeID: f.ProbeID,
+		Name:    f.Name,
+		Labels:  f.Labels,
+		Started: f.Started,
+		Elapsed: f.Elapsed,
+	}
+}
+
+// failureSnapshot returns the state of f.
+func failureSnapshot(f pool.Failure) FailureSnapshot {
+	s := FailureSnapshot{
+		TaskID: f.TaskID,
+		Name:   f.Name,
+		Labels: f.Labels,
+		Error:  f.Err.Error(),
+		Time:   f.Time,
+	}
+	var panicErr *pool.PanicError
+	if errors.As(f.Err, &panicErr) {
+		s.Panic = true
+		s.Stack = string(panicErr.Stack)
+	}
+	return s
+}
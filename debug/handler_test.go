@@ -0,0 +1,138 @@
+package debug
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/amplify-security/probe"
+	"github.com/amplify-security/probe/pool"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestHandler() (*Handler, func()) {
+	p := pool.NewPool(&pool.PoolConfig{
+		Size: 2,
+	})
+	p.Submit(func(_ context.Context) error {
+		panic("boom")
+	}).Wait()
+	p.SubmitNamed("failed", nil, func(_ context.Context) error {
+		return errors.New("failed")
+	}).Wait()
+	started, ctrl := make(chan struct{}), make(chan struct{})
+	p.RunNamed("blocked", map[string]string{"key": "value"}, func() {
+		close(started)
+		<-ctrl
+	})
+	<-started
+	pr := probe.NewProbe(&probe.ProbeConfig{})
+	h := NewHandler()
+	h.AddPool("workers", p)
+	h.AddProbe("single", pr)
+	return h, func() {
+		close(ctrl)
+		p.Stop(true)
+		pr.Stop(true)
+	}
+}
+
+func TestHandler_JSON(t *testing.T) {
+	h, stop := newTestHandler()
+	defer stop()
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/debug/probe?format=json", nil),
+		func() *http.Request {
+			r := httptest.NewRequest(http.MethodGet, "/debug/probe", nil)
+			r.Header.Set("Accept", "application/json")
+			return r
+		}(),
+	} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		assert.Equal(t, "application/json", w.Header().Get("Content-Type"), "ServeHTTP(json) -> Content-Type == application/json")
+		var s Snapshot
+		assert.NoError(t, json.NewDecoder(w.Body).Decode(&s), "ServeHTTP(json) -> valid JSON")
+		assert.Len(t, s.Pools, 1, "ServeHTTP(json) -> len(s.Pools) == 1")
+		assert.Len(t, s.Probes, 1, "ServeHTTP(json) -> len(s.Probes) == 1")
+		ps := s.Pools[0]
+		assert.Equal(t, "workers", ps.Name, "ServeHTTP(json) -> ps.Name == workers")
+		assert.Equal(t, "running", ps.State, "ServeHTTP(json) -> ps.State == running")
+		assert.Equal(t, 2, ps.Size, "ServeHTTP(json) -> ps.Size == 2")
+		assert.Len(t, ps.InFlight, 1, "ServeHTTP(json) -> len(ps.InFlight) == 1")
+		assert.Equal(t, "blocked", ps.InFlight[0].Name, "ServeHTTP(json) -> ps.InFlight[0].Name == blocked")
+		assert.Len(t, ps.Failures, 2, "ServeHTTP(json) -> len(ps.Failures) == 2")
+		assert.True(t, ps.Failures[0].Panic, "ServeHTTP(json) -> ps.Failures[0].Panic == true")
+		assert.NotEmpty(t, ps.Failures[0].Stack, "ServeHTTP(json) -> ps.Failures[0].Stack != \"\"")
+		assert.False(t, ps.Failures[1].Panic, "ServeHTTP(json) -> ps.Failures[1].Panic == false")
+		assert.Equal(t, "failed", ps.Failures[1].Error, "ServeHTTP(json) -> ps.Failures[1].Error == failed")
+		assert.Equal(t, "single", s.Probes[0].Name, "ServeHTTP(json) -> s.Probes[0].Name == single")
+		assert.Equal(t, "running", s.Probes[0].State, "ServeHTTP(json) -> s.Probes[0].State == running")
+		assert.Nil(t, s.Probes[0].InFlight, "ServeHTTP(json) -> s.Probes[0].InFlight == nil")
+	}
+}
+
+func TestHandler_HTML(t *testing.T) {
+	h, stop := newTestHandler()
+	defer stop()
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/debug/probe", nil))
+	assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"), "ServeHTTP -> Content-Type == text/html")
+	body := w.Body.String()
+	assert.Contains(t, body, "workers", "ServeHTTP -> body contains pool name")
+	assert.Contains(t, body, "blocked", "ServeHTTP -> body contains in flight work")
+	assert.Contains(t, body, "pool: task panicked: boom", "ServeHTTP -> body contains panic")
+	assert.Contains(t, body, "single", "ServeHTTP -> body contains probe name")
+}
+
+func TestHandler_RenderError(t *testing.T) {
+	h := NewHandler()
+	defer func(p *template.Template) {
+		page = p
+	}(page)
+	page = template.Must(template.New("page").Parse("{{.Missing}}"))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/debug/probe", nil))
+	assert.Equal(t, http.StatusInternalServerError, w.Code, "ServeHTTP(render error) -> 500")
+	assert.Contains(t, w.Body.String(), "failed to render snapshot", "ServeHTTP(render error) -> error in body")
+}
+
+func TestNewRegistryHandler(t *testing.T) {
+	p := pool.NewPool(&pool.PoolConfig{
+		Name:     "registered-pool",
+		Register: true,
+		Size:     1,
+	})
+	pr := probe.NewProbe(&probe.ProbeConfig{
+		Name:     "registered-probe",
+		Register: true,
+	})
+	s := NewRegistryHandler().Snapshot()
+	assert.Contains(t, poolNames(s), "registered-pool", "NewRegistryHandler -> s.Pools contains registered pool")
+	assert.Contains(t, probeNames(s), "registered-probe", "NewRegistryHandler -> s.Probes contains registered probe")
+	p.Stop(true)
+	pr.Stop(true)
+	s = NewRegistryHandler().Snapshot()
+	assert.NotContains(t, poolNames(s), "registered-pool", "Stop -> s.Pools does not contain pool")
+	assert.NotContains(t, probeNames(s), "registered-probe", "Stop -> s.Probes does not contain probe")
+}
+
+func poolNames(s Snapshot) []string {
+	names := []string{}
+	for _, p := range s.Pools {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+func probeNames(s Snapshot) []string {
+	names := []string{}
+	for _, p := range s.Probes {
+		names = append(names, p.Name)
+	}
+	return names
+}
@@ -0,0 +1,41 @@
+package probe
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"sync/atomic"
+)
+
+type (
+	// IDGenerator generates identifiers for Probes. Implementations must be safe for concurrent use and
+	// never return the same ID twice.
+	IDGenerator interface {
+		NextID() string
+	}
+
+	// SequenceIDGenerator is an IDGenerator that returns a prefix followed by a monotonic index, e.g.
+	// "workers-1". IDs are unique among those returned by the same SequenceIDGenerator.
+	SequenceIDGenerator struct {
+		prefix string
+		seq    atomic.Uint64
+	}
+)
+
+var (
+	// defaultIDGenerator is the IDGenerator shared by all Probes without a configured IDGenerator. Its random
+	// prefix distinguishes Probes of different processes in aggregated logs.
+	defaultIDGenerator = NewSequenceIDGenerator(fmt.Sprintf("%06x", rand.Uint32()&0xffffff))
+)
+
+// NewSequenceIDGenerator initializes and returns a new SequenceIDGenerator with the given prefix.
+func NewSequenceIDGenerator(prefix string) *SequenceIDGenerator {
+	return &SequenceIDGenerator{
+		prefix: prefix,
+	}
+}
+
+// NextID implementation of IDGenerator for SequenceIDGenerator.
+func (g *SequenceIDGenerator) NextID() string {
+	return g.prefix + "-" + strconv.FormatUint(g.seq.Add(1), 10)
+}
@@ -0,0 +1,66 @@
+package pipeline
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/amplify-security/probe/logging"
+	"github.com/amplify-security/probe/pool"
+)
+
+type (
+	// PipelineConfig is a struct for passing configuration data to a new Pipeline.
+	PipelineConfig struct {
+		LogHandler slog.Handler    // Handler to use for pipeline logging. If empty, probe.NoopHandler will be used.
+		Ctx        context.Context // Context to use for the pipeline. If empty, context.Background will be used.
+	}
+
+	// StageConfig is a struct for passing configuration data to a new stage.
+	StageConfig struct {
+		// Configuration of the Pool running the stage. If LogHandler is empty, the LogHandler of the Pipeline is
+		// used. Ctx is ignored.
+		Pool       *pool.PoolConfig
+		BufferSize int  // Size of the output channel buffer. Default is the size of the Pool.
+		Ordered    bool // Re-sequence output in input order.
+	}
+)
+
+// getLogHandler returns the log handler to use for the Pipeline.
+func (c *PipelineConfig) getLogHandler() slog.Handler {
+	if c.LogHandler == nil {
+		return &logging.NoopLogHandler{}
+	}
+	return c.LogHandler
+}
+
+// getCtx returns the context to use for the Pipeline.
+func (c *PipelineConfig) getCtx() context.Context {
+	if c.Ctx == nil {
+		return context.Background()
+	}
+	return c.Ctx
+}
+
+// getPool returns the Pool configuration to use for the stage.
+func (c *StageConfig) getPool() pool.PoolConfig {
+	if c.Pool == nil {
+		return pool.PoolConfig{}
+	}
+	return *c.Pool
+}
+
+// getSize returns the size of the Pool to use for the stage.
+func (c *StageConfig) getSize() int {
+	if c.Pool == nil || c.Pool.Size == 0 {
+		return pool.DefaultPoolSize
+	}
+	return c.Pool.Size
+}
+
+// getBufferSize returns the output channel buffer size to use for the stage.
+func (c *StageConfig) getBufferSize() int {
+	if c.BufferSize == 0 {
+		return c.getSize()
+	}
+	return c.BufferSize
+}
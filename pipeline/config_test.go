@@ -0,0 +1,65 @@
+package pipeline
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/amplify-security/probe/logging"
+	"github.com/amplify-security/probe/pool"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPipelineConfig_getLogHandler(t *testing.T) {
+	cases := []struct {
+		cfg      *PipelineConfig
+		expected slog.Handler
+	}{
+		{&PipelineConfig{}, &logging.NoopLogHandler{}},
+		{&PipelineConfig{LogHandler: logHandler}, logHandler},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.expected, c.cfg.getLogHandler(), "PipelineConfig.getLogHandler -> expected handler")
+	}
+}
+
+func TestPipelineConfig_getCtx(t *testing.T) {
+	ctx := context.WithValue(context.Background(), "key", "value")
+	cases := []struct {
+		cfg      *PipelineConfig
+		expected context.Context
+	}{
+		{&PipelineConfig{}, context.Background()},
+		{&PipelineConfig{Ctx: ctx}, ctx},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.expected, c.cfg.getCtx(), "PipelineConfig.getCtx -> expected context")
+	}
+}
+
+func TestStageConfig_getPool(t *testing.T) {
+	cases := []struct {
+		cfg      *StageConfig
+		expected pool.PoolConfig
+	}{
+		{&StageConfig{}, pool.PoolConfig{}},
+		{&StageConfig{Pool: &pool.PoolConfig{Size: 3}}, pool.PoolConfig{Size: 3}},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.expected, c.cfg.getPool(), "StageConfig.getPool -> expected config")
+	}
+}
+
+func TestStageConfig_getBufferSize(t *testing.T) {
+	cases := []struct {
+		cfg      *StageConfig
+		expected int
+	}{
+		{&StageConfig{}, pool.DefaultPoolSize},
+		{&StageConfig{Pool: &pool.PoolConfig{Size: 3}}, 3},
+		{&StageConfig{Pool: &pool.PoolConfig{Size: 3}, BufferSize: 7}, 7},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.expected, c.cfg.getBufferSize(), "StageConfig.getBufferSize -> expected size")
+	}
+}
@@ -0,0 +1,219 @@
+package pipeline
+
+import (
+	"context"
+	"iter"
+	"log/slog"
+	"sync"
+
+	"github.com/amplify-security/probe/pool"
+)
+
+type (
+	// Pipeline is a chain of stages connected by bounded channels. Every stage runs on its own Pool. A stage
+	// only pulls input while it has an idle Probe and room in its output channel, so a slow stage applies
+	// backpressure to all stages before it. The first error cancels the whole Pipeline.
+	Pipeline struct {
+		log       *slog.Logger
+		ctx       context.Context
+		parentCtx context.Context
+		cancel    context.CancelFunc
+		waitGroup sync.WaitGroup
+		mu        sync.Mutex
+		err       error
+	}
+
+	// Stream is the output of a source or stage of a Pipeline.
+	Stream[T any] struct {
+		p  *Pipeline
+		ch chan T
+	}
+
+	// emitter sends the outputs of a stage to its Stream, re-sequencing them in input order if ordered.
+	emitter[T any] struct {
+		ctx     context.Context
+		ch      chan T
+		slots   chan struct{}
+		ordered bool
+		mu      sync.Mutex
+		next    uint64
+		pending map[uint64]T
+	}
+)
+
+// NewPipeline initializes and returns a new Pipeline.
+func NewPipeline(cfg *PipelineConfig) *Pipeline {
+	parentCtx := cfg.getCtx()
+	ctx, cancel := context.WithCancel(parentCtx)
+	return &Pipeline{
+		log:       slog.New(cfg.getLogHandler()).With("source", "probe.Pipeline"),
+		ctx:       ctx,
+		parentCtx: parentCtx,
+		cancel:    cancel,
+	}
+}
+
+// Wait blocks until all stages are finished and returns the first error of a stage, or the error of the
+// Pipeline context if it was canceled.
+func (p *Pipeline) Wait() error {
+	p.waitGroup.Wait()
+	p.cancel()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.err != nil {
+		return p.err
+	}
+	return p.parentCtx.Err()
+}
+
+// Cancel cancels the Pipeline. All stages stop and Wait returns context.Canceled.
+func (p *Pipeline) Cancel() {
+	p.fail(context.Canceled)
+}
+
+// fail records the first error of the Pipeline and cancels all stages.
+func (p *Pipeline) fail(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.err != nil {
+		return
+	}
+	p.log.Error("pipeline failed", "error", err)
+	p.err = err
+	p.cancel()
+}
+
+// From returns a Stream of the values of seq.
+func From[T any](p *Pipeline, seq iter.Seq[T]) *Stream[T] {
+	s := &Stream[T]{
+		p:  p,
+		ch: make(chan T),
+	}
+	p.waitGroup.Add(1)
+	go func() {
+		defer p.waitGroup.Done()
+		defer close(s.ch)
+		for v := range seq {
+			select {
+			case <-p.ctx.Done():
+				return
+			case s.ch <- v:
+			}
+		}
+	}()
+	return s
+}
+
+// Stage returns a Stream of the outputs of fn for every value of in. fn runs on a new Pool configured by
+// cfg, which is stopped once in is exhausted. If fn returns an error or panics, the Pipeline is canceled.
+// cfg may be nil.
+func Stage[In, Out any](in *Stream[In], fn func(ctx context.Context, in In) (Out, error),
+	cfg *StageConfig) *Stream[Out] {
+	if cfg == nil {
+		cfg = &StageConfig{}
+	}
+	p := in.p
+	out := &Stream[Out]{
+		p:  p,
+		ch: make(chan Out, cfg.getBufferSize()),
+	}
+	poolCfg := cfg.getPool()
+	// the stage stops its pool once all submitted work is done, so the pool must outlive a canceled pipeline
+	poolCfg.Ctx = context.WithoutCancel(p.ctx)
+	if poolCfg.LogHandler == nil {
+		poolCfg.LogHandler = p.log.Handler()
+	}
+	workers := pool.NewPool(&poolCfg)
+	e := &emitter[Out]{
+		ctx:     p.ctx,
+		ch:      out.ch,
+		slots:   make(chan struct{}, cfg.getSize()),
+		ordered: cfg.Ordered,
+		pending: map[uint64]Out{},
+	}
+	p.waitGroup.Add(1)
+	go func() {
+		defer p.waitGroup.Done()
+		defer close(out.ch)
+		tasks := new(sync.WaitGroup)
+		defer workers.Stop(true)
+		defer tasks.Wait()
+		var seq uint64
+		for v := range in.ch {
+			// a slot is held until the output is sent, so a full output channel stops the stage pulling input
+			select {
+			case <-p.ctx.Done():
+				return
+			case e.slots <- struct{}{}:
+			}
+			tasks.Add(1)
+			n := seq
+			workers.Run(func() {
+				defer tasks.Done()
+				if p.ctx.Err() != nil {
+					<-e.slots
+					return
+				}
+				var result Out
+				err := pool.CallTask(p.ctx, func(ctx context.Context) error {
+					var err error
+					result, err = fn(ctx, v)
+					return err
+				})
+				if err != nil {
+					<-e.slots
+					p.fail(err)
+					return
+				}
+				e.emit(n, result)
+			})
+			seq++
+		}
+	}()
+	return out
+}
+
+// Sink calls fn for every value of in on a new Pool configured by cfg. If fn returns an error or panics,
+// the Pipeline is canceled. cfg may be nil.
+func Sink[T any](in *Stream[T], fn func(ctx context.Context, v T) error, cfg *StageConfig) {
+	out := Stage(in, func(ctx context.Context, v T) (struct{}, error) {
+		return struct{}{}, fn(ctx, v)
+	}, cfg)
+	p := in.p
+	p.waitGroup.Add(1)
+	go func() {
+		defer p.waitGroup.Done()
+		for range out.ch {
+		}
+	}()
+}
+
+// emit sends the output for the input with the given sequence number. If the emitter is ordered, the
+// output is held until the outputs of all earlier inputs are sent. A slot is released for every output.
+func (e *emitter[T]) emit(seq uint64, v T) {
+	if !e.ordered {
+		e.send(v)
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.pending[seq] = v
+	for {
+		v, ok := e.pending[e.next]
+		if !ok {
+			return
+		}
+		delete(e.pending, e.next)
+		e.send(v)
+		e.next++
+	}
+}
+
+// send sends v to the Stream unless the Pipeline is canceled, then releases a slot.
+func (e *emitter[T]) send(v T) {
+	select {
+	case <-e.ctx.Done():
+	case e.ch <- v:
+	}
+	<-e.slots
+}
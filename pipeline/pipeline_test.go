@@ -0,0 +1,187 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/amplify-security/probe/logging"
+	"github.com/amplify-security/probe/pool"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	logHandler = &logging.NoopLogHandler{}
+)
+
+func TestPipeline(t *testing.T) {
+	p := NewPipeline(&PipelineConfig{LogHandler: logHandler})
+	numbers := From(p, slices.Values([]int{5, 1, 4, 2, 3, 0, 7, 6}))
+	squares := Stage(numbers, func(_ context.Context, in int) (int, error) {
+		// later items finish first, output must still be in input order
+		time.Sleep(time.Duration(in) * time.Millisecond)
+		return in * in, nil
+	}, &StageConfig{Pool: &pool.PoolConfig{Size: 4}, Ordered: true})
+	strs := Stage(squares, func(_ context.Context, in int) (string, error) {
+		return strconv.Itoa(in), nil
+	}, &StageConfig{Ordered: true})
+	var out []string
+	Sink(strs, func(_ context.Context, v string) error {
+		out = append(out, v)
+		return nil
+	}, &StageConfig{Pool: &pool.PoolConfig{Size: 1}})
+	assert.NoError(t, p.Wait(), "Pipeline.Wait -> err == nil")
+	assert.Equal(t, []string{"25", "1", "16", "4", "9", "0", "49", "36"}, out, "Pipeline(Ordered) -> outputs in input order")
+}
+
+func TestPipeline_Unordered(t *testing.T) {
+	p := NewPipeline(&PipelineConfig{LogHandler: logHandler})
+	numbers := From(p, func(yield func(int) bool) {
+		for i := range 100 {
+			if !yield(i) {
+				return
+			}
+		}
+	})
+	doubled := Stage(numbers, func(_ context.Context, in int) (int, error) {
+		return in * 2, nil
+	}, nil)
+	mu := new(sync.Mutex)
+	var out []int
+	Sink(doubled, func(_ context.Context, v int) error {
+		mu.Lock()
+		defer mu.Unlock()
+		out = append(out, v)
+		return nil
+	}, nil)
+	assert.NoError(t, p.Wait(), "Pipeline.Wait -> err == nil")
+	slices.Sort(out)
+	expected := make([]int, 100)
+	for i := range expected {
+		expected[i] = i * 2
+	}
+	assert.Equal(t, expected, out, "Pipeline -> all outputs delivered")
+}
+
+func TestPipeline_Backpressure(t *testing.T) {
+	p := NewPipeline(&PipelineConfig{LogHandler: logHandler})
+	pulled := new(atomic.Int32)
+	numbers := From(p, func(yield func(int) bool) {
+		for i := range 100 {
+			pulled.Add(1)
+			if !yield(i) {
+				return
+			}
+		}
+	})
+	fast := Stage(numbers, func(_ context.Context, in int) (int, error) {
+		return in, nil
+	}, &StageConfig{Pool: &pool.PoolConfig{Size: 2}, BufferSize: 2})
+	release := make(chan struct{})
+	Sink(fast, func(_ context.Context, _ int) error {
+		<-release
+		return nil
+	}, &StageConfig{Pool: &pool.PoolConfig{Size: 1}})
+	time.Sleep(50 * time.Millisecond)
+	// the sink holds 1 slot, the fast stage holds 2 slots and 2 buffered outputs, each handoff holds 1 more
+	assert.LessOrEqual(t, int(pulled.Load()), 10, "Pipeline(slow sink) -> source is throttled")
+	close(release)
+	assert.NoError(t, p.Wait(), "Pipeline.Wait -> err == nil")
+	assert.Equal(t, int32(100), pulled.Load(), "Pipeline -> all inputs pulled")
+}
+
+func TestPipeline_Error(t *testing.T) {
+	p := NewPipeline(&PipelineConfig{LogHandler: logHandler})
+	errBoom := errors.New("boom")
+	numbers := From(p, func(yield func(int) bool) {
+		for i := 0; ; i++ {
+			if !yield(i) {
+				return
+			}
+		}
+	})
+	checked := Stage(numbers, func(_ context.Context, in int) (int, error) {
+		if in == 10 {
+			return 0, errBoom
+		}
+		return in, nil
+	}, &StageConfig{Ordered: true})
+	Sink(checked, func(_ context.Context, _ int) error {
+		return nil
+	}, nil)
+	assert.ErrorIs(t, p.Wait(), errBoom, "Pipeline(error) -> Wait returns stage error")
+}
+
+func TestPipeline_Panic(t *testing.T) {
+	p := NewPipeline(&PipelineConfig{LogHandler: logHandler})
+	numbers := From(p, slices.Values([]int{1, 2, 3}))
+	Sink(numbers, func(_ context.Context, v int) error {
+		if v == 2 {
+			panic("boom")
+		}
+		return nil
+	}, nil)
+	err := p.Wait()
+	var panicErr *pool.PanicError
+	assert.ErrorAs(t, err, &panicErr, "Pipeline(panic) -> err is *pool.PanicError")
+	assert.Equal(t, "boom", panicErr.Value, "Pipeline(panic) -> PanicError.Value")
+}
+
+func TestPipeline_Cancel(t *testing.T) {
+	infinite := func(yield func(int) bool) {
+		for i := 0; ; i++ {
+			if !yield(i) {
+				return
+			}
+		}
+	}
+	p := NewPipeline(&PipelineConfig{LogHandler: logHandler})
+	started := make(chan struct{})
+	var once sync.Once
+	Sink(From(p, infinite), func(ctx context.Context, _ int) error {
+		once.Do(func() { close(started) })
+		<-ctx.Done()
+		return nil
+	}, nil)
+	<-started
+	p.Cancel()
+	assert.ErrorIs(t, p.Wait(), context.Canceled, "Pipeline.Cancel -> Wait returns context.Canceled")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	p = NewPipeline(&PipelineConfig{LogHandler: logHandler, Ctx: ctx})
+	Sink(From(p, infinite), func(_ context.Context, _ int) error {
+		return nil
+	}, nil)
+	assert.ErrorIs(t, p.Wait(), context.DeadlineExceeded, "Pipeline(parent ctx done) -> Wait returns ctx error")
+}
+
+func TestPipeline_CancelWhileWaiting(t *testing.T) {
+	for range 20 {
+		p := NewPipeline(&PipelineConfig{LogHandler: logHandler})
+		consumed := make(chan struct{})
+		n := 0
+		Sink(From(p, slices.Values([]int{1, 2, 3})), func(_ context.Context, _ int) error {
+			if n++; n == 3 {
+				close(consumed)
+			}
+			return nil
+		}, &StageConfig{Pool: &pool.PoolConfig{Size: 1}})
+		<-consumed
+		done := make(chan error)
+		go func() {
+			done <- p.Wait()
+		}()
+		// let the stages exit and Wait read the error, so nothing orders Cancel before Wait
+		time.Sleep(time.Millisecond)
+		p.Cancel()
+		err := <-done
+		if err != nil {
+			assert.ErrorIs(t, err, context.Canceled, "Pipeline.Cancel(while waiting) -> context.Canceled or nil")
+		}
+	}
+}
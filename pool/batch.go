@@ -0,0 +1,228 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+type (
+	// ItemErrors is an error returned by BatcherConfig.Process to fail individual items of a batch. It holds
+	// one error per item in item order, nil for items that succeeded.
+	ItemErrors []error
+
+	// BatchHandle tracks an item submitted to a Batcher.
+	BatchHandle[Out any] struct {
+		done chan struct{}
+		out  Out
+		err  error
+	}
+
+	// Batcher collects items submitted from many goroutines into batches and processes each batch as a
+	// single Task on a Pool. A batch is dispatched once it holds BatcherConfig.MaxSize items or
+	// BatcherConfig.MaxLatency after its first item, whichever comes first.
+	Batcher[In, Out any] struct {
+		pool       *Pool
+		name       string
+		maxSize    int
+		maxLatency time.Duration
+		process    func(ctx context.Context, items []In) ([]Out, error)
+		mu         sync.Mutex
+		items      []In
+		handles    []*BatchHandle[Out]
+		timer      *time.Timer
+		batch      uint64
+		closed     bool
+	}
+)
+
+var (
+	ErrBatcherClosed = errors.New("pool: batcher closed") // ErrBatcherClosed is the error of items submitted to a closed Batcher.
+	// ErrBatchSize is the error of a batch whose Process function returned a different number of outputs
+	// than items.
+	ErrBatchSize = errors.New("pool: batch output count does not match item count")
+)
+
+// Error implementation of error for ItemErrors.
+func (e ItemErrors) Error() string {
+	return fmt.Sprintf("pool: %d of %d batch items failed", len(e.Unwrap()), len(e))
+}
+
+// Unwrap returns the errors of the failed items.
+func (e ItemErrors) Unwrap() []error {
+	errs := make([]error, 0, len(e))
+	for _, err := range e {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// NewBatcher initializes and returns a new Batcher that processes batches on p.
+func NewBatcher[In, Out any](p *Pool, cfg *BatcherConfig[In, Out]) *Batcher[In, Out] {
+	return &Batcher[In, Out]{
+		pool:       p,
+		name:       cfg.Name,
+		maxSize:    cfg.getMaxSize(),
+		maxLatency: cfg.getMaxLatency(),
+		process:    cfg.Process,
+		items:      make([]In, 0, cfg.getMaxSize()),
+	}
+}
+
+// Submit adds an item to the current batch and returns a BatchHandle that receives its output. Submit
+// dispatches the batch if it is full, blocking while the queue of the Pool is full.
+func (b *Batcher[In, Out]) Submit(in In) *BatchHandle[Out] {
+	h := &BatchHandle[Out]{done: make(chan struct{})}
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		var out Out
+		h.deliver(out, ErrBatcherClosed)
+		return h
+	}
+	b.items = append(b.items, in)
+	b.handles = append(b.handles, h)
+	if len(b.items) == 1 {
+		batch := b.batch
+		b.timer = time.AfterFunc(b.maxLatency, func() {
+			b.flush(batch)
+		})
+	}
+	var items []In
+	var handles []*BatchHandle[Out]
+	if len(b.items) >= b.maxSize {
+		items, handles = b.takeLocked()
+	}
+	b.mu.Unlock()
+	if items != nil {
+		b.dispatch(items, handles)
+	}
+	return h
+}
+
+// Flush dispatches the current batch without waiting for it to fill up.
+func (b *Batcher[In, Out]) Flush() {
+	b.mu.Lock()
+	items, handles := b.takeLocked()
+	b.mu.Unlock()
+	if items != nil {
+		b.dispatch(items, handles)
+	}
+}
+
+// Close dispatches the current batch and stops accepting items. Items submitted after Close fail with
+// ErrBatcherClosed.
+func (b *Batcher[In, Out]) Close() {
+	b.mu.Lock()
+	b.closed = true
+	items, handles := b.takeLocked()
+	b.mu.Unlock()
+	if items != nil {
+		b.dispatch(items, handles)
+	}
+}
+
+// Pending returns the number of items in the current batch.
+func (b *Batcher[In, Out]) Pending() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.items)
+}
+
+// flush dispatches the batch with the given sequence number when its latency timer fires, unless it was
+// already dispatched.
+func (b *Batcher[In, Out]) flush(batch uint64) {
+	b.mu.Lock()
+	if batch != b.batch {
+		b.mu.Unlock()
+		return
+	}
+	items, handles := b.takeLocked()
+	b.mu.Unlock()
+	if items != nil {
+		b.dispatch(items, handles)
+	}
+}
+
+// takeLocked removes and returns the current batch and starts a new one. takeLocked returns nil if the
+// current batch is empty. The caller must hold b.mu.
+func (b *Batcher[In, Out]) takeLocked() ([]In, []*BatchHandle[Out]) {
+	if len(b.items) == 0 {
+		return nil, nil
+	}
+	b.timer.Stop()
+	items, handles := b.items, b.handles
+	b.items, b.handles = make([]In, 0, b.maxSize), nil
+	b.batch++
+	return items, handles
+}
+
+// dispatch submits a batch to the Pool. If the Task of the batch is cancelled before it runs, all items
+// fail with its error.
+func (b *Batcher[In, Out]) dispatch(items []In, handles []*BatchHandle[Out]) {
+	var delivered sync.Once
+	h := b.pool.SubmitNamed(b.name, nil, func(ctx context.Context) error {
+		var outs []Out
+		err := CallTask(ctx, func(ctx context.Context) error {
+			var err error
+			outs, err = b.process(ctx, items)
+			return err
+		})
+		var itemErrs ItemErrors
+		if !errors.As(err, &itemErrs) || len(itemErrs) != len(items) {
+			itemErrs = nil
+		}
+		switch {
+		case err != nil && itemErrs == nil:
+			// the whole batch failed
+			outs = nil
+		case len(outs) != len(items):
+			outs, itemErrs, err = nil, nil, ErrBatchSize
+		}
+		delivered.Do(func() {
+			for i, h := range handles {
+				var out Out
+				if outs != nil {
+					out = outs[i]
+				}
+				itemErr := err
+				if itemErrs != nil {
+					itemErr = itemErrs[i]
+				}
+				h.deliver(out, itemErr)
+			}
+		})
+		return err
+	})
+	go func() {
+		<-h.Done()
+		delivered.Do(func() {
+			// the Task was cancelled before it ran
+			for _, bh := range handles {
+				bh.deliver(*new(Out), h.Err())
+			}
+		})
+	}()
+}
+
+// deliver records the output of the item and closes done.
+func (h *BatchHandle[Out]) deliver(out Out, err error) {
+	h.out, h.err = out, err
+	close(h.done)
+}
+
+// Done returns a channel that is closed when the output of the item is available.
+func (h *BatchHandle[Out]) Done() <-chan struct{} {
+	return h.done
+}
+
+// Wait blocks until the batch of the item is processed and returns the output and error of the item.
+// Panics are reported as a *PanicError.
+func (h *BatchHandle[Out]) Wait() (Out, error) {
+	<-h.done
+	return h.out, h.err
+}
@@ -0,0 +1,217 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBatcher_MaxSize(t *testing.T) {
+	p := NewPool(&PoolConfig{
+		LogHandler: logHandler,
+		Size:       2,
+	})
+	defer p.Stop(true)
+	mu := new(sync.Mutex)
+	var sizes []int
+	b := NewBatcher(p, &BatcherConfig[int, string]{
+		MaxSize:    4,
+		MaxLatency: time.Hour,
+		Process: func(_ context.Context, items []int) ([]string, error) {
+			mu.Lock()
+			sizes = append(sizes, len(items))
+			mu.Unlock()
+			outs := make([]string, len(items))
+			for i, item := range items {
+				outs[i] = strconv.Itoa(item)
+			}
+			return outs, nil
+		},
+	})
+	handles := make([]*BatchHandle[string], 8)
+	for i := range handles {
+		handles[i] = b.Submit(i)
+	}
+	for i, h := range handles {
+		out, err := h.Wait()
+		assert.NoError(t, err, "BatchHandle.Wait -> err == nil")
+		assert.Equal(t, strconv.Itoa(i), out, "BatchHandle.Wait -> output of item")
+	}
+	assert.Equal(t, []int{4, 4}, sizes, "Batcher(MaxSize: 4) -> 2 full batches")
+	assert.Equal(t, 0, b.Pending(), "Batcher.Pending -> 0")
+}
+
+func TestBatcher_MaxLatency(t *testing.T) {
+	p := NewPool(&PoolConfig{
+		LogHandler: logHandler,
+		Size:       1,
+	})
+	defer p.Stop(true)
+	b := NewBatcher(p, &BatcherConfig[int, int]{
+		MaxLatency: 10 * time.Millisecond,
+		Process: func(_ context.Context, items []int) ([]int, error) {
+			return items, nil
+		},
+	})
+	h := b.Submit(1)
+	assert.Equal(t, 1, b.Pending(), "Batcher.Pending -> 1")
+	select {
+	case <-h.Done():
+	case <-time.After(time.Second):
+		assert.Fail(t, "Batcher(MaxLatency: 10ms) -> batch dispatched after latency")
+	}
+	out, err := h.Wait()
+	assert.NoError(t, err, "BatchHandle.Wait -> err == nil")
+	assert.Equal(t, 1, out, "BatchHandle.Wait -> 1")
+}
+
+func TestBatcher_Errors(t *testing.T) {
+	p := NewPool(&PoolConfig{
+		LogHandler: logHandler,
+		Size:       1,
+	})
+	defer p.Stop(true)
+	errOdd, errBatch := errors.New("odd"), errors.New("batch")
+	cases := []struct {
+		process  func(ctx context.Context, items []int) ([]int, error)
+		expected []error
+		msg      string
+	}{
+		{
+			process: func(_ context.Context, items []int) ([]int, error) {
+				errs := make(ItemErrors, len(items))
+				for i, item := range items {
+					if item%2 == 1 {
+						errs[i] = errOdd
+					}
+				}
+				return items, errs
+			},
+			expected: []error{nil, errOdd, nil, errOdd},
+			msg:      "ItemErrors -> per item errors",
+		},
+		{
+			process: func(_ context.Context, items []int) ([]int, error) {
+				return nil, errBatch
+			},
+			expected: []error{errBatch, errBatch, errBatch, errBatch},
+			msg:      "error -> batch error for all items",
+		},
+		{
+			process: func(_ context.Context, items []int) ([]int, error) {
+				return items[1:], nil
+			},
+			expected: []error{ErrBatchSize, ErrBatchSize, ErrBatchSize, ErrBatchSize},
+			msg:      "short outputs -> ErrBatchSize",
+		},
+	}
+	for _, c := range cases {
+		b := NewBatcher(p, &BatcherConfig[int, int]{
+			MaxSize: 4,
+			Process: c.process,
+		})
+		handles := make([]*BatchHandle[int], 4)
+		for i := range handles {
+			handles[i] = b.Submit(i)
+		}
+		for i, h := range handles {
+			_, err := h.Wait()
+			if c.expected[i] == nil {
+				assert.NoError(t, err, "Batcher("+c.msg+")")
+			} else {
+				assert.ErrorIs(t, err, c.expected[i], "Batcher("+c.msg+")")
+			}
+		}
+	}
+	failures := p.Failures()
+	if assert.Len(t, failures, 3, "Pool.Failures -> 3 failed batches") {
+		var itemErrs ItemErrors
+		assert.ErrorAs(t, failures[0].Err, &itemErrs, "Pool.Failures[0] -> ItemErrors")
+		assert.EqualError(t, itemErrs, "pool: 2 of 4 batch items failed", "ItemErrors.Error -> expected message")
+	}
+}
+
+func TestBatcher_Panic(t *testing.T) {
+	p := NewPool(&PoolConfig{
+		LogHandler: logHandler,
+		Size:       1,
+	})
+	defer p.Stop(true)
+	b := NewBatcher(p, &BatcherConfig[int, int]{
+		Process: func(_ context.Context, _ []int) ([]int, error) {
+			panic("boom")
+		},
+	})
+	h := b.Submit(1)
+	b.Flush()
+	_, err := h.Wait()
+	var panicErr *PanicError
+	assert.ErrorAs(t, err, &panicErr, "Batcher(panic) -> err is *PanicError")
+}
+
+func TestBatcher_Close(t *testing.T) {
+	p := NewPool(&PoolConfig{
+		LogHandler: logHandler,
+		Size:       4,
+	})
+	defer p.Stop(true)
+	b := NewBatcher(p, &BatcherConfig[int, int]{
+		MaxSize:    16,
+		MaxLatency: time.Hour,
+		Process: func(_ context.Context, items []int) ([]int, error) {
+			return items, nil
+		},
+	})
+	wg := new(sync.WaitGroup)
+	handles := make([]*BatchHandle[int], 40)
+	for i := range handles {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			handles[i] = b.Submit(i)
+		}()
+	}
+	wg.Wait()
+	b.Close()
+	for i, h := range handles {
+		out, err := h.Wait()
+		assert.NoError(t, err, "BatchHandle.Wait -> err == nil")
+		assert.Equal(t, i, out, "BatchHandle.Wait -> output of item")
+	}
+	_, err := b.Submit(1).Wait()
+	assert.ErrorIs(t, err, ErrBatcherClosed, "Batcher.Submit(closed) -> ErrBatcherClosed")
+}
+
+func TestBatcher_Cancelled(t *testing.T) {
+	p := NewPool(&PoolConfig{
+		LogHandler: logHandler,
+		Size:       1,
+	})
+	defer p.Stop(true)
+	started, release := make(chan struct{}), make(chan struct{})
+	p.Submit(func(_ context.Context) error {
+		close(started)
+		<-release
+		return nil
+	})
+	<-started
+	defer close(release)
+	b := NewBatcher(p, &BatcherConfig[int, int]{
+		Process: func(_ context.Context, items []int) ([]int, error) {
+			return items, nil
+		},
+	})
+	h := b.Submit(1)
+	b.Flush()
+	// cancel the queued Task of the batch while the only Probe is busy
+	w, ok := p.queue.Pop(context.Background())
+	assert.True(t, ok, "Pool.queue.Pop -> batch Task")
+	w.Cancel()
+	_, err := h.Wait()
+	assert.ErrorIs(t, err, ErrTaskCancelled, "Batcher(cancelled Task) -> ErrTaskCancelled")
+}
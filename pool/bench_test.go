@@ -0,0 +1,131 @@
+package pool
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/amplify-security/probe"
+)
+
+type (
+	// benchPool is the submission surface shared by Pool and ShardedPool.
+	benchPool interface {
+		Run(r probe.Runner)
+		Stop(wait bool) error
+	}
+)
+
+/*
+These benchmarks compare the throughput and submission-to-start latency of the Pool schedulers under
+concurrent submission. Run them with:
+
+	go test ./pool -run '^$' -bench . -cpu 1,4,16
+
+ns/op is the throughput per submitted task. p50-ns and p99-ns are the latency from Run until a Probe
+starts executing the task.
+*/
+
+func benchmarkPool(b *testing.B, p benchPool) {
+	defer p.Stop(true)
+	latencies := make([]int64, b.N)
+	idx := new(atomic.Int64)
+	wg := new(sync.WaitGroup)
+	wg.Add(b.N)
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			submitted := time.Now()
+			p.Run(func() {
+				latencies[idx.Add(1)-1] = int64(time.Since(submitted))
+				wg.Done()
+			})
+		}
+	})
+	wg.Wait()
+	b.StopTimer()
+	slices.Sort(latencies)
+	b.ReportMetric(float64(latencies[len(latencies)/2]), "p50-ns")
+	b.ReportMetric(float64(latencies[len(latencies)*99/100]), "p99-ns")
+}
+
+func BenchmarkPool_Scheduling(b *testing.B) {
+	cases := []struct {
+		name       string
+		scheduling Scheduling
+		queue      QueueType
+	}{
+		{
+			name:       "fifo",
+			scheduling: SchedulingFIFO,
+		},
+		{
+			name:       "fifo-ring",
+			scheduling: SchedulingFIFO,
+			queue:      QueueRing,
+		},
+		{
+			name:       "work-stealing",
+			scheduling: SchedulingWorkStealing,
+		},
+	}
+	for _, c := range cases {
+		for _, size := range []int{4, 16, 64} {
+			b.Run(fmt.Sprintf("%s/size=%d", c.name, size), func(b *testing.B) {
+				benchmarkPool(b, NewPool(&PoolConfig{
+					Size:       size,
+					BufferSize: 1024,
+					Scheduling: c.scheduling,
+					Queue:      c.queue,
+				}))
+			})
+		}
+	}
+}
+
+func BenchmarkPool_Queue(b *testing.B) {
+	cases := []struct {
+		name  string
+		queue QueueType
+	}{
+		{
+			name:  "chan",
+			queue: QueueChan,
+		},
+		{
+			name:  "ring",
+			queue: QueueRing,
+		},
+	}
+	for _, c := range cases {
+		// submitters is the number of submitting goroutines per GOMAXPROCS, the contention on the queue
+		for _, submitters := range []int{1, 4, 16} {
+			b.Run(fmt.Sprintf("%s/submitters=%d", c.name, submitters), func(b *testing.B) {
+				b.SetParallelism(submitters)
+				benchmarkPool(b, NewPool(&PoolConfig{
+					Size:       16,
+					BufferSize: 1024,
+					Queue:      c.queue,
+				}))
+			})
+		}
+	}
+}
+
+func BenchmarkShardedPool(b *testing.B) {
+	for _, shards := range []int{2, 4, 8} {
+		b.Run(fmt.Sprintf("shards=%d", shards), func(b *testing.B) {
+			// keep the total size and buffer comparable with BenchmarkPool_Scheduling/size=64
+			benchmarkPool(b, NewShardedPool(&ShardedPoolConfig{
+				Shards: shards,
+				Shard: &PoolConfig{
+					Size:       64 / shards,
+					BufferSize: 1024 / shards,
+				},
+			}))
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package pool
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+type (
+	// Coalescer submits keyed Tasks to a Pool so that Tasks with the same key share a single execution.
+	// A Task submitted while another Task with the same key is queued or running is not executed. Instead,
+	// it receives the result of the Task in flight. Successful results can be cached for a short time.
+	Coalescer[K comparable, V any] struct {
+		pool  *Pool
+		name  string
+		ttl   time.Duration
+		mu    sync.Mutex
+		calls map[K]*coalescedCall[V]
+	}
+
+	// coalescedCall is a single execution of a keyed Task shared by all its callers.
+	coalescedCall[V any] struct {
+		done    chan struct{}
+		val     V
+		err     error
+		expires time.Time
+	}
+
+	// CoalescedHandle tracks a keyed Task submitted to a Coalescer.
+	CoalescedHandle[V any] struct {
+		call   *coalescedCall[V]
+		shared bool
+	}
+)
+
+// NewCoalescer initializes and returns a new Coalescer that runs Tasks on p. cfg may be nil.
+func NewCoalescer[K comparable, V any](p *Pool, cfg *CoalescerConfig) *Coalescer[K, V] {
+	if cfg == nil {
+		cfg = &CoalescerConfig{}
+	}
+	return &Coalescer[K, V]{
+		pool:  p,
+		name:  cfg.Name,
+		ttl:   cfg.TTL,
+		calls: map[K]*coalescedCall[V]{},
+	}
+}
+
+// Submit executes fn on a Probe in the Pool unless a Task with the same key is in flight or its result is
+// cached, in which case the returned CoalescedHandle shares that result. The key is reported as the "key"
+// label by InFlight. A Task that panics fails with a *PanicError.
+func (c *Coalescer[K, V]) Submit(key K, fn func(ctx context.Context) (V, error)) *CoalescedHandle[V] {
+	c.mu.Lock()
+	if call, ok := c.calls[key]; ok && !call.expired() {
+		c.mu.Unlock()
+		return &CoalescedHandle[V]{call: call, shared: true}
+	}
+	call := &coalescedCall[V]{done: make(chan struct{})}
+	c.calls[key] = call
+	c.mu.Unlock()
+	var finished sync.Once
+	h := c.pool.SubmitNamed(c.name, map[string]string{"key": fmt.Sprint(key)}, func(ctx context.Context) error {
+		err := CallTask(ctx, func(ctx context.Context) error {
+			var err error
+			call.val, err = fn(ctx)
+			return err
+		})
+		finished.Do(func() {
+			call.err = err
+			c.finish(key, call)
+		})
+		return err
+	})
+	go func() {
+		<-h.Done()
+		finished.Do(func() {
+			// the Task was cancelled before it ran
+			call.err = h.Err()
+			c.finish(key, call)
+		})
+	}()
+	return &CoalescedHandle[V]{call: call}
+}
+
+// Forget removes the Task in flight or the cached result for key, so the next Submit with key executes
+// again. Callers already sharing the Task still receive its result.
+func (c *Coalescer[K, V]) Forget(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.calls, key)
+}
+
+// finish publishes the result of call and removes it, or caches it for the TTL if it succeeded.
+func (c *Coalescer[K, V]) finish(key K, call *coalescedCall[V]) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cache := c.ttl > 0 && call.err == nil
+	if cache {
+		call.expires = time.Now().Add(c.ttl)
+	}
+	close(call.done)
+	if c.calls[key] != call {
+		// the call was forgotten
+		return
+	}
+	if !cache {
+		delete(c.calls, key)
+		return
+	}
+	time.AfterFunc(c.ttl, func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if c.calls[key] == call {
+			delete(c.calls, key)
+		}
+	})
+}
+
+// expired returns true if the call finished and its cached result has expired. The caller must hold the
+// mutex of the Coalescer.
+func (c *coalescedCall[V]) expired() bool {
+	return !c.expires.IsZero() && time.Now().After(c.expires)
+}
+
+// Done returns a channel that is closed when the result of the Task is available.
+func (h *CoalescedHandle[V]) Done() <-chan struct{} {
+	return h.call.done
+}
+
+// Wait blocks until the Task finishes and returns its result.
+func (h *CoalescedHandle[V]) Wait() (V, error) {
+	<-h.call.done
+	return h.call.val, h.call.err
+}
+
+// Shared returns true if the handle shares the result of a Task submitted by another caller, either in
+// flight or cached.
+func (h *CoalescedHandle[V]) Shared() bool {
+	return h.shared
+}
@@ -0,0 +1,138 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCoalescer_Submit(t *testing.T) {
+	p := NewPool(&PoolConfig{
+		LogHandler: logHandler,
+		Size:       4,
+	})
+	defer p.Stop(true)
+	c := NewCoalescer[string, int](p, nil)
+	calls := new(atomic.Int32)
+	release := make(chan struct{})
+	fn := func(_ context.Context) (int, error) {
+		<-release
+		return int(calls.Add(1)), nil
+	}
+	handles := make([]*CoalescedHandle[int], 10)
+	wg := new(sync.WaitGroup)
+	for i := range handles {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			handles[i] = c.Submit("key", fn)
+		}()
+	}
+	wg.Wait()
+	close(release)
+	shared := 0
+	for _, h := range handles {
+		v, err := h.Wait()
+		assert.NoError(t, err, "CoalescedHandle.Wait -> err == nil")
+		assert.Equal(t, 1, v, "CoalescedHandle.Wait -> result of the single execution")
+		if h.Shared() {
+			shared++
+		}
+	}
+	assert.Equal(t, int32(1), calls.Load(), "Coalescer.Submit(same key) -> 1 execution")
+	assert.Equal(t, 9, shared, "CoalescedHandle.Shared -> 9 shared handles")
+	v, _ := c.Submit("key", fn).Wait()
+	assert.Equal(t, 2, v, "Coalescer.Submit(finished, no TTL) -> executes again")
+	v, _ = c.Submit("other", fn).Wait()
+	assert.Equal(t, 3, v, "Coalescer.Submit(other key) -> separate execution")
+}
+
+func TestCoalescer_TTL(t *testing.T) {
+	p := NewPool(&PoolConfig{
+		LogHandler: logHandler,
+		Size:       1,
+	})
+	defer p.Stop(true)
+	c := NewCoalescer[int, int](p, &CoalescerConfig{TTL: 50 * time.Millisecond})
+	calls := new(atomic.Int32)
+	fn := func(_ context.Context) (int, error) {
+		return int(calls.Add(1)), nil
+	}
+	v, _ := c.Submit(1, fn).Wait()
+	assert.Equal(t, 1, v, "Coalescer.Submit -> 1")
+	h := c.Submit(1, fn)
+	v, _ = h.Wait()
+	assert.Equal(t, 1, v, "Coalescer.Submit(cached) -> cached result")
+	assert.True(t, h.Shared(), "CoalescedHandle.Shared(cached) -> true")
+	c.Forget(1)
+	v, _ = c.Submit(1, fn).Wait()
+	assert.Equal(t, 2, v, "Coalescer.Submit(forgotten) -> executes again")
+	time.Sleep(100 * time.Millisecond)
+	v, _ = c.Submit(1, fn).Wait()
+	assert.Equal(t, 3, v, "Coalescer.Submit(expired) -> executes again")
+	errBoom := errors.New("boom")
+	_, err := c.Submit(2, func(_ context.Context) (int, error) {
+		return 0, errBoom
+	}).Wait()
+	assert.ErrorIs(t, err, errBoom, "Coalescer.Submit(error) -> error")
+	v, err = c.Submit(2, fn).Wait()
+	assert.NoError(t, err, "Coalescer.Submit(after error) -> errors are not cached")
+	assert.Equal(t, 4, v, "Coalescer.Submit(after error) -> executes again")
+}
+
+func TestCoalescer_Panic(t *testing.T) {
+	p := NewPool(&PoolConfig{
+		LogHandler: logHandler,
+		Size:       1,
+	})
+	defer p.Stop(true)
+	c := NewCoalescer[int, int](p, &CoalescerConfig{Name: "coalesced"})
+	_, err := c.Submit(1, func(_ context.Context) (int, error) {
+		panic("boom")
+	}).Wait()
+	var panicErr *PanicError
+	assert.ErrorAs(t, err, &panicErr, "Coalescer.Submit(panic) -> err is *PanicError")
+	failures := p.Failures()
+	if assert.Len(t, failures, 1, "Pool.Failures -> 1 failure") {
+		assert.Equal(t, "coalesced", failures[0].Name, "Failure.Name -> coalesced")
+		assert.Equal(t, map[string]string{"key": "1"}, failures[0].Labels, "Failure.Labels -> key")
+	}
+}
+
+func TestCoalescer_Cancelled(t *testing.T) {
+	p := NewPool(&PoolConfig{
+		LogHandler: logHandler,
+		Size:       1,
+	})
+	defer p.Stop(true)
+	started, release := make(chan struct{}), make(chan struct{})
+	p.Submit(func(_ context.Context) error {
+		close(started)
+		<-release
+		return nil
+	})
+	<-started
+	c := NewCoalescer[int, int](p, &CoalescerConfig{TTL: time.Hour})
+	h := c.Submit(1, func(_ context.Context) (int, error) {
+		return 1, nil
+	})
+	// cancel the queued Task while the only Probe is busy
+	w, ok := p.queue.Pop(context.Background())
+	assert.True(t, ok, "Pool.queue.Pop -> coalesced Task")
+	w.Cancel()
+	_, err := h.Wait()
+	assert.ErrorIs(t, err, ErrTaskCancelled, "Coalescer(cancelled Task) -> ErrTaskCancelled")
+	// skip the cancelled Task like a Probe would
+	w.Runner()
+	close(release)
+	v, err := c.Submit(1, func(_ context.Context) (int, error) {
+		return 2, nil
+	}).Wait()
+	assert.NoError(t, err, "Coalescer.Submit(after cancelled) -> err == nil")
+	assert.Equal(t, 2, v, "Coalescer.Submit(after cancelled) -> executes again")
+}
@@ -3,23 +3,184 @@ package pool
 import (
 	"context"
 	"log/slog"
+	"runtime"
+	"sync"
+	"time"
 
+	"github.com/amplify-security/probe"
 	"github.com/amplify-security/probe/logging"
 )
 
 const (
-	DefaultPoolSize   = 8  // DefaultPoolSize is the default size of the pool.
-	DefaultBufferSize = 64 // DefaultBufferSize is the default size of the work channel buffer.
+	SchedulingFIFO Scheduling = iota // SchedulingFIFO runs work in submission order. This is the default.
+	SchedulingEDF                    // SchedulingEDF runs work with the earliest deadline first.
+	// SchedulingWorkStealing gives each Probe a local queue. Work is spread across the local queues and idle
+	// Probes steal work from busy Probes, reducing contention on a single shared channel.
+	SchedulingWorkStealing
+)
+
+const (
+	QueueChan QueueType = iota // QueueChan uses a buffered channel as the work queue. This is the default.
+	// QueueRing uses a lock-free bounded ring buffer as the work queue. It is meant for many goroutines
+	// submitting work concurrently; measure with BenchmarkPool_Queue before choosing it over QueueChan. The
+	// buffer size is rounded up to a power of two.
+	QueueRing
+)
+
+const (
+	DedupOff DedupMode = iota // DedupOff queues every Task submitted with SubmitKeyed. This is the default.
+	// DedupReplace replaces a queued Task with the same key with the newly submitted Task (last write wins).
+	// The queued Task keeps its position in the queue.
+	DedupReplace
+	// DedupDrop drops a newly submitted Task if a Task with the same key is queued.
+	DedupDrop
+)
+
+const (
+	DefaultPoolSize         = 8           // DefaultPoolSize is the default size of the pool.
+	DefaultBufferSize       = 64          // DefaultBufferSize is the default size of the work channel buffer.
+	DefaultWatchdogInterval = time.Second // DefaultWatchdogInterval is the default interval between watchdog scans.
+)
+
+const (
+	DefaultBatchSize    = 64                    // DefaultBatchSize is the default maximum size of a batch.
+	DefaultBatchLatency = 10 * time.Millisecond // DefaultBatchLatency is the default maximum latency of a batch.
+)
+
+const (
+	DefaultDialTimeout = 5 * time.Second // DefaultDialTimeout is the default timeout for connecting to a Server.
+	DefaultAckTimeout  = 5 * time.Second // DefaultAckTimeout is the default timeout for a Server to accept a task.
+)
+
+const (
+	// DefaultCompactThreshold is the default number of acknowledged journal records that triggers compaction.
+	DefaultCompactThreshold = 1024
 )
 
 type (
+	// Scheduling is the strategy Probes in a Pool use to pick up work.
+	Scheduling int
+
+	// QueueType is the implementation of the shared work queue of a Pool.
+	QueueType int
+
+	// DedupMode is the handling of a Task submitted with SubmitKeyed while a Task with the same key is queued.
+	DedupMode int
+
 	// PoolConfig is a struct for passing configuration data to a new Pool.
 	PoolConfig struct {
-		LogHandler slog.Handler    // Handler to use for pool logging. If empty, probe.NoopHandler will be used.
-		Ctx        context.Context // Context to use for the pool. If empty, context.Background will be used.
-		Size       int             // Size of the pool. Default pool size is 8.
-		BufferSize int             // Size of the work channel buffer. Default buffer size is 64.
+		// Name of the pool, used in logs and the registry. Optional. Without an IDGenerator, probes are numbered
+		// after Name, e.g. "workers-1", by a counter shared process-wide by all Pools with the same Name.
+		Name        string
+		Register    bool              // Add the pool to the process-wide registry while it is running. Requires Name.
+		IDGenerator probe.IDGenerator // Generator of the probe IDs. If empty, probes are numbered after Name if set.
+		LogHandler  slog.Handler      // Handler to use for pool logging. If empty, probe.NoopHandler will be used.
+		Ctx         context.Context   // Context to use for the pool. If empty, context.Background will be used.
+		Size        int               // Size of the pool. Default pool size is 8.
+		BufferSize  int               // Size of the work channel buffer. Default buffer size is 64.
+		Scheduling  Scheduling        // Scheduling mode of the pool. Default is SchedulingFIFO.
+		Queue       QueueType         // Work queue for SchedulingFIFO, ignored otherwise. Default is QueueChan.
+		// Dedup is the deduplication of queued Tasks submitted with SubmitKeyed. It is layered on top of the
+		// queue of any Scheduling mode, which keeps ordering the Tasks. Default is DedupOff.
+		Dedup DedupMode
+		// OnExpired is called when a Runner submitted with RunWithDeadline is dropped because its deadline
+		// passed before a Probe picked it up. OnExpired is called from a Probe goroutine and should not block.
+		OnExpired func(deadline time.Time)
+		Watchdog  *WatchdogConfig // Watchdog for stuck work. If empty, the watchdog is disabled.
+		Tasks     *TaskRegistry   // Registry of the Handlers of named tasks submitted with SubmitDescriptor. Optional.
+	}
+
+	// WatchdogConfig is a struct for passing configuration data to the stuck work watchdog of a Pool.
+	WatchdogConfig struct {
+		Interval time.Duration // Interval between scans. Default is half the smallest threshold.
+		Warn     time.Duration // Elapsed time after which work is reported as StuckWarn. If empty, it is not reported.
+		Hard     time.Duration // Elapsed time after which work is reported as StuckHard. If empty, it is not reported.
+		// Cancel cancels work when it is reported as StuckHard. Only Tasks submitted with Submit or SubmitNamed
+		// can be cancelled.
+		Cancel bool
+		// OnStuck is called for every report of stuck work. OnStuck is called from the watchdog goroutine and
+		// should not block.
+		OnStuck func(Stuck)
 	}
+
+	// StatefulPoolConfig is a struct for passing configuration data to a new StatefulPool.
+	StatefulPoolConfig[S any] struct {
+		Pool  *PoolConfig       // Configuration of the Pool. Scheduling, Queue and Dedup are ignored.
+		Init  func() (S, error) // Creates the state of a Probe when it starts. If empty, the zero value of S is used.
+		Close func(S)           // Releases the state of a Probe when it stops. Optional.
+	}
+
+	// WorkerConfig is a struct for passing configuration data to a new Worker.
+	WorkerConfig[In, Out any] struct {
+		Pool             *PoolConfig // Configuration of the Pool. Scheduling, Queue and Dedup are ignored.
+		ResultBufferSize int         // Size of the result channel buffer. Default is the BufferSize of the Pool.
+		// Process is called for every input. Required.
+		Process func(ctx context.Context, in In) (Out, error)
+	}
+
+	// MapConfig is a struct for passing configuration data to Map, ForEach and their variants.
+	MapConfig struct {
+		MaxInFlight int  // Maximum number of items submitted to the Pool at once. Default is the size of the Pool.
+		StopOnError bool // Stop submitting items and cancel items in flight on the first error.
+	}
+
+	// BatcherConfig is a struct for passing configuration data to a new Batcher.
+	BatcherConfig[In, Out any] struct {
+		Name       string        // Name of the batch Tasks, reported by InFlight. Optional.
+		MaxSize    int           // Number of items that dispatches a batch. Default is 64.
+		MaxLatency time.Duration // Time after the first item of a batch that dispatches it. Default is 10ms.
+		// Process is called with every batch and returns one output per item, in item order. Returning an
+		// ItemErrors fails individual items, any other error fails the whole batch. Required.
+		Process func(ctx context.Context, items []In) ([]Out, error)
+	}
+
+	// CoalescerConfig is a struct for passing configuration data to a new Coalescer.
+	CoalescerConfig struct {
+		Name string        // Name of the coalesced Tasks, reported by InFlight. Optional.
+		TTL  time.Duration // Time successful results are cached after the Task finishes. If empty, results are not cached.
+	}
+
+	// DurablePoolConfig is a struct for passing configuration data to a new DurablePool.
+	DurablePoolConfig struct {
+		// Configuration of the Pool. Tasks is the registry of the Handlers that can be enqueued and is required.
+		// Scheduling, Queue, Dedup and BufferSize are ignored, as the journaled queue is unbounded.
+		Pool *PoolConfig
+		Path string // Path of the journal file. It is created if it does not exist. Required.
+		// Number of acknowledged records in the journal that triggers compaction. Default is 1024.
+		CompactThreshold int
+	}
+
+	// ServerConfig is a struct for passing configuration data to a new Server.
+	ServerConfig struct {
+		LogHandler slog.Handler // Handler to use for server logging. If empty, probe.NoopHandler will be used.
+	}
+
+	// RemotePoolConfig is a struct for passing configuration data to a new RemotePool.
+	RemotePoolConfig struct {
+		Network     string        // Network of the Server, "unix" or "tcp". Required.
+		Address     string        // Address of the Server, a socket path or a loopback host:port. Required.
+		LogHandler  slog.Handler  // Handler to use for client logging. If empty, probe.NoopHandler will be used.
+		DialTimeout time.Duration // Timeout for connecting to the Server. Default is 5s.
+		AckTimeout  time.Duration // Timeout for the Server to accept a task. Default is 5s.
+		// Timeout for a task to finish once accepted. The task is cancelled on the Server when it expires, and
+		// fails with ErrRemoteTimeout if the Server does not report it within AckTimeout. If empty, tasks do
+		// not time out.
+		TaskTimeout time.Duration
+	}
+
+	// ShardedPoolConfig is a struct for passing configuration data to a new ShardedPool.
+	ShardedPoolConfig struct {
+		Shards int // Number of shards. Default is runtime.GOMAXPROCS(0).
+		// Configuration for each shard. Size and BufferSize apply per shard. If Name is set, each shard is
+		// named after it with the shard index as a suffix.
+		Shard *PoolConfig
+	}
+)
+
+var (
+	// namedIDGenerators maps Pool names to the SequenceIDGenerator shared by all Pools with that name, so
+	// that probe IDs stay unique in the process when Pools are recreated or share a name.
+	namedIDGenerators sync.Map
 )
 
 // getLogHandler returns the log handler to use for the Pool.
@@ -30,6 +191,16 @@ func (c *PoolConfig) getLogHandler() slog.Handler {
 	return c.LogHandler
 }
 
+// getIDGenerator returns the probe ID generator to use for the Pool. getIDGenerator returns nil to use the
+// default of probe.ProbeConfig.
+func (c *PoolConfig) getIDGenerator() probe.IDGenerator {
+	if c.IDGenerator == nil && c.Name != "" {
+		g, _ := namedIDGenerators.LoadOrStore(c.Name, probe.NewSequenceIDGenerator(c.Name))
+		return g.(*probe.SequenceIDGenerator)
+	}
+	return c.IDGenerator
+}
+
 // getCtx returns the context to use for the Pool.
 func (c *PoolConfig) getCtx() context.Context {
 	if c.Ctx == nil {
@@ -53,3 +224,142 @@ func (c *PoolConfig) getBufferSize() int {
 	}
 	return c.BufferSize
 }
+
+// getInterval returns the interval between scans to use for the watchdog.
+func (c *WatchdogConfig) getInterval() time.Duration {
+	if c.Interval != 0 {
+		return c.Interval
+	}
+	threshold := c.Warn
+	if threshold == 0 || (c.Hard != 0 && c.Hard < threshold) {
+		threshold = c.Hard
+	}
+	if threshold == 0 {
+		return DefaultWatchdogInterval
+	}
+	return threshold / 2
+}
+
+// getPool returns the Pool configuration to use for the StatefulPool.
+func (c *StatefulPoolConfig[S]) getPool() PoolConfig {
+	if c.Pool == nil {
+		return PoolConfig{}
+	}
+	return *c.Pool
+}
+
+// getInit returns the state constructor to use for the StatefulPool.
+func (c *StatefulPoolConfig[S]) getInit() func() (S, error) {
+	if c.Init == nil {
+		return func() (S, error) {
+			var state S
+			return state, nil
+		}
+	}
+	return c.Init
+}
+
+// getPool returns the Pool configuration to use for the Worker.
+func (c *WorkerConfig[In, Out]) getPool() PoolConfig {
+	if c.Pool == nil {
+		return PoolConfig{}
+	}
+	return *c.Pool
+}
+
+// getResultBufferSize returns the result channel buffer size to use for the Worker.
+func (c *WorkerConfig[In, Out]) getResultBufferSize() int {
+	if c.ResultBufferSize == 0 {
+		pool := c.getPool()
+		return pool.getBufferSize()
+	}
+	return c.ResultBufferSize
+}
+
+// getMaxInFlight returns the maximum number of items in flight to use for a Pool with the given size.
+func (c *MapConfig) getMaxInFlight(size int) int {
+	if c.MaxInFlight == 0 {
+		return size
+	}
+	return c.MaxInFlight
+}
+
+// getMaxSize returns the maximum batch size to use for the Batcher.
+func (c *BatcherConfig[In, Out]) getMaxSize() int {
+	if c.MaxSize == 0 {
+		return DefaultBatchSize
+	}
+	return c.MaxSize
+}
+
+// getMaxLatency returns the maximum batch latency to use for the Batcher.
+func (c *BatcherConfig[In, Out]) getMaxLatency() time.Duration {
+	if c.MaxLatency == 0 {
+		return DefaultBatchLatency
+	}
+	return c.MaxLatency
+}
+
+// getPool returns the Pool configuration to use for the DurablePool.
+func (c *DurablePoolConfig) getPool() PoolConfig {
+	if c.Pool == nil {
+		return PoolConfig{}
+	}
+	return *c.Pool
+}
+
+// getCompactThreshold returns the number of acknowledged journal records that triggers compaction.
+func (c *DurablePoolConfig) getCompactThreshold() int {
+	if c.CompactThreshold == 0 {
+		return DefaultCompactThreshold
+	}
+	return c.CompactThreshold
+}
+
+// getLogHandler returns the log handler to use for the Server.
+func (c *ServerConfig) getLogHandler() slog.Handler {
+	if c.LogHandler == nil {
+		return &logging.NoopLogHandler{}
+	}
+	return c.LogHandler
+}
+
+// getLogHandler returns the log handler to use for the RemotePool.
+func (c *RemotePoolConfig) getLogHandler() slog.Handler {
+	if c.LogHandler == nil {
+		return &logging.NoopLogHandler{}
+	}
+	return c.LogHandler
+}
+
+// getDialTimeout returns the timeout for connecting to the Server.
+func (c *RemotePoolConfig) getDialTimeout() time.Duration {
+	if c.DialTimeout == 0 {
+		return DefaultDialTimeout
+	}
+	return c.DialTimeout
+}
+
+// getAckTimeout returns the timeout for the Server to accept a task.
+func (c *RemotePoolConfig) getAckTimeout() time.Duration {
+	if c.AckTimeout == 0 {
+		return DefaultAckTimeout
+	}
+	return c.AckTimeout
+}
+
+// getShards returns the number of shards to use for the ShardedPool.
+func (c *ShardedPoolConfig) getShards() int {
+	if c.Shards == 0 {
+		return runtime.GOMAXPROCS(0)
+	}
+	return c.Shards
+}
+
+// getShard returns the configuration to use for each shard of the ShardedPool.
+func (c *ShardedPoolConfig) getShard() PoolConfig {
+	if c.Shard == nil {
+		return PoolConfig{}
+	}
+	return *c.Shard
+}
@@ -3,8 +3,12 @@ package pool
 import (
 	"context"
 	"log/slog"
+	"runtime"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/amplify-security/probe"
 	"github.com/amplify-security/probe/logging"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -141,3 +145,161 @@ func TestPoolConfig_getBufferSize(t *testing.T) {
 		}
 	}
 }
+
+func TestShardedPoolConfig_getShards(t *testing.T) {
+	cases := []struct {
+		shards int
+		msg    string
+	}{
+		{
+			shards: 1,
+			msg:    "getShards -> 1",
+		},
+		{
+			shards: 0,
+			msg:    "getShards -> runtime.GOMAXPROCS(0)",
+		},
+	}
+	for _, c := range cases {
+		cfg := &ShardedPoolConfig{
+			Shards: c.shards,
+		}
+		if c.shards != 0 {
+			assert.Equal(t, c.shards, cfg.getShards(), c.msg)
+		} else {
+			assert.Equal(t, runtime.GOMAXPROCS(0), cfg.getShards(), c.msg)
+		}
+	}
+}
+
+func TestShardedPoolConfig_getShard(t *testing.T) {
+	cases := []struct {
+		shard *PoolConfig
+		msg   string
+	}{
+		{
+			shard: &PoolConfig{Size: 1},
+			msg:   "getShard -> PoolConfig{Size: 1}",
+		},
+		{
+			shard: nil,
+			msg:   "getShard -> PoolConfig{}",
+		},
+	}
+	for _, c := range cases {
+		cfg := &ShardedPoolConfig{
+			Shard: c.shard,
+		}
+		if c.shard != nil {
+			assert.Equal(t, *c.shard, cfg.getShard(), c.msg)
+		} else {
+			assert.Equal(t, PoolConfig{}, cfg.getShard(), c.msg)
+		}
+	}
+}
+
+func TestWatchdogConfig_getInterval(t *testing.T) {
+	cases := []struct {
+		cfg      *WatchdogConfig
+		interval time.Duration
+		msg      string
+	}{
+		{
+			cfg:      &WatchdogConfig{Interval: time.Minute, Warn: time.Second},
+			interval: time.Minute,
+			msg:      "getInterval -> Interval",
+		},
+		{
+			cfg:      &WatchdogConfig{Warn: 4 * time.Second, Hard: 10 * time.Second},
+			interval: 2 * time.Second,
+			msg:      "getInterval -> Warn / 2",
+		},
+		{
+			cfg:      &WatchdogConfig{Warn: 4 * time.Second, Hard: 2 * time.Second},
+			interval: time.Second,
+			msg:      "getInterval(Hard < Warn) -> Hard / 2",
+		},
+		{
+			cfg:      &WatchdogConfig{Hard: 2 * time.Second},
+			interval: time.Second,
+			msg:      "getInterval(no Warn) -> Hard / 2",
+		},
+		{
+			cfg:      &WatchdogConfig{},
+			interval: DefaultWatchdogInterval,
+			msg:      "getInterval -> DefaultWatchdogInterval",
+		},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.interval, c.cfg.getInterval(), c.msg)
+	}
+}
+
+func TestPoolConfig_getIDGenerator(t *testing.T) {
+	g := probe.NewSequenceIDGenerator("custom")
+	cases := []struct {
+		cfg *PoolConfig
+		id  string
+		msg string
+	}{
+		{
+			cfg: &PoolConfig{IDGenerator: g, Name: "named"},
+			id:  "custom-1",
+			msg: "getIDGenerator(g) -> g",
+		},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.id, c.cfg.getIDGenerator().NextID(), c.msg)
+	}
+	// the sequence of a name is shared by the whole process, so only the prefix and uniqueness are stable
+	first := (&PoolConfig{Name: "config-named"}).getIDGenerator().NextID()
+	second := (&PoolConfig{Name: "config-named"}).getIDGenerator().NextID()
+	assert.True(t, strings.HasPrefix(first, "config-named-"), "getIDGenerator(Name) -> Name sequence")
+	assert.True(t, strings.HasPrefix(second, "config-named-"), "getIDGenerator(Name) -> Name sequence")
+	assert.NotEqual(t, first, second, "getIDGenerator(Name) -> shared Name sequence")
+	assert.Nil(t, (&PoolConfig{}).getIDGenerator(), "getIDGenerator -> nil")
+}
+
+func TestStatefulPoolConfig_getInit(t *testing.T) {
+	cfg := &StatefulPoolConfig[int]{
+		Init: func() (int, error) {
+			return 1, nil
+		},
+	}
+	state, err := cfg.getInit()()
+	assert.Equal(t, 1, state, "getInit(init) -> init")
+	assert.NoError(t, err, "getInit(init) -> err == nil")
+	cfg = &StatefulPoolConfig[int]{}
+	state, err = cfg.getInit()()
+	assert.Equal(t, 0, state, "getInit(nil) -> zero state")
+	assert.NoError(t, err, "getInit(nil) -> err == nil")
+	assert.Equal(t, PoolConfig{}, cfg.getPool(), "getPool(nil) -> PoolConfig{}")
+}
+
+func TestMapConfig_getMaxInFlight(t *testing.T) {
+	assert.Equal(t, 4, (&MapConfig{MaxInFlight: 4}).getMaxInFlight(8), "getMaxInFlight(4) -> 4")
+	assert.Equal(t, 8, (&MapConfig{}).getMaxInFlight(8), "getMaxInFlight -> size")
+}
+
+func TestBatcherConfig_getMaxSize(t *testing.T) {
+	assert.Equal(t, 4, (&BatcherConfig[int, int]{MaxSize: 4}).getMaxSize(), "getMaxSize(4) -> 4")
+	assert.Equal(t, DefaultBatchSize, (&BatcherConfig[int, int]{}).getMaxSize(), "getMaxSize -> DefaultBatchSize")
+}
+
+func TestBatcherConfig_getMaxLatency(t *testing.T) {
+	assert.Equal(t, time.Second, (&BatcherConfig[int, int]{MaxLatency: time.Second}).getMaxLatency(),
+		"getMaxLatency(1s) -> 1s")
+	assert.Equal(t, DefaultBatchLatency, (&BatcherConfig[int, int]{}).getMaxLatency(),
+		"getMaxLatency -> DefaultBatchLatency")
+}
+
+func TestRemotePoolConfig_getDialTimeout(t *testing.T) {
+	assert.Equal(t, time.Second, (&RemotePoolConfig{DialTimeout: time.Second}).getDialTimeout(),
+		"getDialTimeout(1s) -> 1s")
+	assert.Equal(t, DefaultDialTimeout, (&RemotePoolConfig{}).getDialTimeout(), "getDialTimeout -> DefaultDialTimeout")
+}
+
+func TestRemotePoolConfig_getAckTimeout(t *testing.T) {
+	assert.Equal(t, time.Second, (&RemotePoolConfig{AckTimeout: time.Second}).getAckTimeout(), "getAckTimeout(1s) -> 1s")
+	assert.Equal(t, DefaultAckTimeout, (&RemotePoolConfig{}).getAckTimeout(), "getAckTimeout -> DefaultAckTimeout")
+}
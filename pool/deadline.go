@@ -0,0 +1,183 @@
+package pool
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/amplify-security/probe"
+)
+
+type (
+	// deadlineTask is Work waiting in a deadlineQueue.
+	deadlineTask struct {
+		work     probe.Work
+		deadline time.Time
+		seq      uint64
+		index    int // index of the task in the heap, or -1 once it left the heap
+	}
+
+	// deadlineHeap is a min-heap of deadlineTasks ordered by deadline, then by submission order.
+	deadlineHeap []*deadlineTask
+
+	// deadlineQueue is a bounded probe.Queue that releases Work in earliest-deadline-first order.
+	// Work whose deadline has passed is dropped instead of being returned by Pop.
+	deadlineQueue struct {
+		mu     sync.Mutex
+		tasks  deadlineHeap
+		seq    uint64
+		slots  chan struct{}
+		notify chan struct{}
+		expire func(deadline time.Time)
+	}
+)
+
+// Len implementation of heap.Interface for deadlineHeap.
+func (h deadlineHeap) Len() int {
+	return len(h)
+}
+
+// Less implementation of heap.Interface for deadlineHeap. Tasks without a deadline sort after all
+// tasks with a deadline.
+func (h deadlineHeap) Less(i, j int) bool {
+	a, b := h[i], h[j]
+	if a.deadline.IsZero() != b.deadline.IsZero() {
+		return b.deadline.IsZero()
+	}
+	if !a.deadline.Equal(b.deadline) {
+		return a.deadline.Before(b.deadline)
+	}
+	return a.seq < b.seq
+}
+
+// Swap implementation of heap.Interface for deadlineHeap.
+func (h deadlineHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+// Push implementation of heap.Interface for deadlineHeap.
+func (h *deadlineHeap) Push(x any) {
+	t := x.(*deadlineTask)
+	t.index = len(*h)
+	*h = append(*h, t)
+}
+
+// Pop implementation of heap.Interface for deadlineHeap.
+func (h *deadlineHeap) Pop() any {
+	old := *h
+	n := len(old)
+	t := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	t.index = -1
+	return t
+}
+
+// newDeadlineQueue initializes and returns a new deadlineQueue that holds at most size Work items.
+// expire is called for all Work dropped because its deadline passed.
+func newDeadlineQueue(size int, expire func(deadline time.Time)) *deadlineQueue {
+	return &deadlineQueue{
+		tasks:  make(deadlineHeap, 0, size),
+		slots:  make(chan struct{}, size),
+		notify: make(chan struct{}, 1),
+		expire: expire,
+	}
+}
+
+// Push implementation of probe.Queue for deadlineQueue. Work pushed without a deadline runs after all
+// Work with a deadline.
+func (q *deadlineQueue) Push(w probe.Work) {
+	q.PushDeadline(w, time.Time{})
+}
+
+// PushDeadline adds Work with a deadline to the queue, blocking while the queue is full.
+func (q *deadlineQueue) PushDeadline(w probe.Work, deadline time.Time) {
+	q.push(w, deadline)
+}
+
+// PushTask implementation of taskQueue for deadlineQueue. The Task runs after all Work with a deadline.
+func (q *deadlineQueue) PushTask(h *TaskHandle, w probe.Work) {
+	t := q.push(w, time.Time{})
+	h.setRemove(func() bool {
+		return q.remove(t)
+	})
+}
+
+// push adds Work with a deadline to the queue, blocking while the queue is full, and returns its task.
+func (q *deadlineQueue) push(w probe.Work, deadline time.Time) *deadlineTask {
+	q.slots <- struct{}{}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.seq++
+	t := &deadlineTask{
+		work:     w,
+		deadline: deadline,
+		seq:      q.seq,
+	}
+	heap.Push(&q.tasks, t)
+	q.signal()
+	return t
+}
+
+// remove removes a task that is still in the queue and frees its slot. remove returns false if the task
+// has already been popped.
+func (q *deadlineQueue) remove(t *deadlineTask) bool {
+	q.mu.Lock()
+	if t.index < 0 {
+		q.mu.Unlock()
+		return false
+	}
+	heap.Remove(&q.tasks, t.index)
+	q.mu.Unlock()
+	<-q.slots
+	return true
+}
+
+// signal wakes up a Probe waiting in Pop.
+func (q *deadlineQueue) signal() {
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+}
+
+// Pop implementation of probe.Queue for deadlineQueue.
+func (q *deadlineQueue) Pop(ctx context.Context) (probe.Work, bool) {
+	for {
+		if ctx.Err() != nil {
+			return probe.Work{}, false
+		}
+		q.mu.Lock()
+		if len(q.tasks) == 0 {
+			q.mu.Unlock()
+			select {
+			case <-ctx.Done():
+				return probe.Work{}, false
+			case <-q.notify:
+				continue
+			}
+		}
+		t := heap.Pop(&q.tasks).(*deadlineTask)
+		if len(q.tasks) > 0 {
+			// pass the wake up on to the next Probe
+			q.signal()
+		}
+		q.mu.Unlock()
+		<-q.slots
+		if !t.deadline.IsZero() && time.Now().After(t.deadline) {
+			q.expire(t.deadline)
+			continue
+		}
+		return t.work, true
+	}
+}
+
+// Len implementation of probe.Queue for deadlineQueue.
+func (q *deadlineQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.tasks)
+}
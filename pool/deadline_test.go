@@ -0,0 +1,73 @@
+package pool
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/amplify-security/probe"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeadlineQueue_Pop(t *testing.T) {
+	now := time.Now()
+	order := []int{}
+	q := newDeadlineQueue(8, func(_ time.Time) {})
+	push := func(i int, deadline time.Time) {
+		q.PushDeadline(probe.Work{Runner: func() {
+			order = append(order, i)
+		}}, deadline)
+	}
+	push(0, time.Time{})
+	push(1, now.Add(3*time.Hour))
+	push(2, now.Add(time.Hour))
+	push(3, time.Time{})
+	push(4, now.Add(2*time.Hour))
+	push(5, now.Add(time.Hour))
+	assert.Equal(t, 6, q.Len(), "PushDeadline x6 -> q.Len == 6")
+	ctx := context.Background()
+	for range 6 {
+		w, ok := q.Pop(ctx)
+		assert.True(t, ok, "Pop -> ok == true")
+		w.Runner()
+	}
+	assert.Equal(t, []int{2, 5, 4, 1, 0, 3}, order, "Pop -> earliest deadline first")
+	assert.Equal(t, 0, q.Len(), "Pop x6 -> q.Len == 0")
+}
+
+func TestDeadlineQueue_Expired(t *testing.T) {
+	var expired []time.Time
+	q := newDeadlineQueue(8, func(deadline time.Time) {
+		expired = append(expired, deadline)
+	})
+	past := time.Now().Add(-time.Second)
+	q.PushDeadline(probe.Work{Runner: func() {}}, past)
+	q.Push(probe.Work{Runner: func() {}})
+	ctx, cancel := context.WithCancel(context.Background())
+	_, ok := q.Pop(ctx)
+	assert.True(t, ok, "Pop -> ok == true")
+	assert.Equal(t, []time.Time{past}, expired, "Pop -> expired == [past]")
+	cancel()
+	_, ok = q.Pop(ctx)
+	assert.False(t, ok, "Pop(cancelled ctx) -> ok == false")
+}
+
+func TestDeadlineQueue_PushTask(t *testing.T) {
+	q := newDeadlineQueue(2, func(_ time.Time) {})
+	hs := []*TaskHandle{newTaskHandle(1), newTaskHandle(2)}
+	for _, h := range hs {
+		q.PushTask(h, probe.Work{Runner: func() {}})
+	}
+	assert.True(t, hs[0].Cancel(), "Cancel(queued) -> true")
+	assert.Equal(t, 1, q.Len(), "Cancel(queued) -> q.Len == 1")
+	// the slot of the cancelled task is free
+	q.Push(probe.Work{Runner: func() {}})
+	ctx := context.Background()
+	for range 2 {
+		_, ok := q.Pop(ctx)
+		assert.True(t, ok, "Pop -> ok == true")
+	}
+	assert.False(t, q.remove(&deadlineTask{index: -1}), "remove(popped) -> false")
+	assert.True(t, hs[1].Cancel(), "Cancel(popped) -> true")
+	assert.Equal(t, 0, q.Len(), "Pop x2 -> q.Len == 0")
+}
@@ -0,0 +1,99 @@
+package pool
+
+import (
+	"sync"
+
+	"github.com/amplify-security/probe"
+)
+
+type (
+	// dedupEntry is a keyed Task waiting in a dedupQueue. work is read when a Probe runs the entry, so a
+	// replacement submitted while it waits is the one that runs.
+	dedupEntry struct {
+		h    *TaskHandle
+		work probe.Work
+	}
+
+	// dedupQueue deduplicates queued Tasks by idempotency key on top of the probe.Queue of the scheduling
+	// mode of a Pool, which keeps ordering the Work. Only Tasks that are waiting are deduplicated: once a
+	// Probe picks up a Task, a Task with the same key is queued again.
+	dedupQueue struct {
+		probe.Queue
+		mode DedupMode
+		mu   sync.Mutex
+		keys map[string]*dedupEntry
+	}
+)
+
+// newDedupQueue initializes and returns a new dedupQueue that deduplicates Tasks queued on q.
+func newDedupQueue(q probe.Queue, mode DedupMode) *dedupQueue {
+	return &dedupQueue{
+		Queue: q,
+		mode:  mode,
+		keys:  map[string]*dedupEntry{},
+	}
+}
+
+// PushKeyed adds the Task tracked by h with an idempotency key, blocking while the queue is full. work
+// returns the Work that runs the Task for a TaskHandle. If a Task with the same key is queued, PushKeyed
+// replaces or drops the new Task according to the DedupMode of the queue and returns the TaskHandle of the
+// queued Task, which then tracks the result for both submitters. Otherwise PushKeyed returns h.
+func (q *dedupQueue) PushKeyed(key string, h *TaskHandle, work func(h *TaskHandle) probe.Work) *TaskHandle {
+	q.mu.Lock()
+	if e, ok := q.keys[key]; ok && e.h.Status() == TaskQueued {
+		if q.mode == DedupReplace {
+			e.work = work(e.h)
+		}
+		q.mu.Unlock()
+		return e.h
+	}
+	// a cancelled Task with the same key is no longer queued, so it is replaced in the index
+	e := &dedupEntry{h: h, work: work(h)}
+	q.keys[key] = e
+	// h is not shared yet, a Task removed from the queue when cancelled never runs to leave the index
+	onCancel := h.onCancel
+	h.onCancel = func(removed bool) {
+		if removed {
+			q.forget(key, e)
+		}
+		if onCancel != nil {
+			onCancel(removed)
+		}
+	}
+	w := probe.Work{
+		Runner: func() {
+			run := q.forget(key, e)
+			run()
+		},
+		Name:   e.work.Name,
+		Labels: e.work.Labels,
+		Cancel: e.work.Cancel,
+	}
+	q.mu.Unlock()
+	// duplicates submitted while this blocks on a full queue share the entry
+	pushTask(q.Queue, h, w)
+	return h
+}
+
+// forget removes e from the index if it is still the entry of key, and returns the Runner of e.
+func (q *dedupQueue) forget(key string, e *dedupEntry) probe.Runner {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.keys[key] == e {
+		delete(q.keys, key)
+	}
+	return e.work.Runner
+}
+
+// PushTask implementation of taskQueue for dedupQueue. Tasks are removed by the queue below deduplication.
+func (q *dedupQueue) PushTask(h *TaskHandle, w probe.Work) {
+	pushTask(q.Queue, h, w)
+}
+
+// unwrapQueue returns the probe.Queue of the scheduling mode of a Pool, below deduplication.
+func unwrapQueue(q probe.Queue) probe.Queue {
+	if d, ok := q.(*dedupQueue); ok {
+		return d.Queue
+	}
+	return q
+}
@@ -0,0 +1,218 @@
+package pool
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/amplify-security/probe"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPool_SubmitKeyed(t *testing.T) {
+	cases := []struct {
+		mode     DedupMode
+		expected []string
+		msg      string
+	}{
+		{
+			mode:     DedupReplace,
+			expected: []string{"a3", "b2", "", ""},
+			msg:      "DedupReplace -> last queued task per key runs",
+		},
+		{
+			mode:     DedupDrop,
+			expected: []string{"a1", "b1", "", ""},
+			msg:      "DedupDrop -> first queued task per key runs",
+		},
+		{
+			mode:     DedupOff,
+			expected: []string{"a1", "b1", "a2", "b2", "a3", "", ""},
+			msg:      "DedupOff -> all tasks run",
+		},
+	}
+	for _, c := range cases {
+		p := NewPool(&PoolConfig{
+			LogHandler: logHandler,
+			Size:       1,
+			Dedup:      c.mode,
+		})
+		p.Pause()
+		mu := new(sync.Mutex)
+		var ran []string
+		task := func(name string) Task {
+			return func(_ context.Context) error {
+				mu.Lock()
+				defer mu.Unlock()
+				ran = append(ran, name)
+				return nil
+			}
+		}
+		var handles []*TaskHandle
+		for _, s := range []struct{ key, name string }{
+			{"a", "a1"}, {"b", "b1"}, {"a", "a2"}, {"b", "b2"}, {"a", "a3"}, {"", ""}, {"", ""},
+		} {
+			handles = append(handles, p.SubmitKeyed(s.key, task(s.name)))
+		}
+		p.Resume()
+		for _, h := range handles {
+			assert.NoError(t, h.Wait(), "TaskHandle.Wait -> err == nil")
+		}
+		assert.Equal(t, c.expected, ran, c.msg)
+		if c.mode != DedupOff {
+			assert.Same(t, handles[0], handles[4], c.msg+" -> shared handle for key a")
+			assert.Same(t, handles[1], handles[3], c.msg+" -> shared handle for key b")
+			assert.NotSame(t, handles[5], handles[6], c.msg+" -> empty keys are not deduplicated")
+		}
+		p.Stop(true)
+	}
+}
+
+func TestPool_SubmitKeyedRequeue(t *testing.T) {
+	p := NewPool(&PoolConfig{
+		LogHandler: logHandler,
+		Size:       1,
+		Dedup:      DedupDrop,
+	})
+	defer p.Stop(true)
+	started, release := make(chan struct{}), make(chan struct{})
+	running := p.SubmitKeyed("key", func(_ context.Context) error {
+		close(started)
+		<-release
+		return nil
+	})
+	<-started
+	// a running task is no longer queued, so the same key is queued again
+	queued := p.SubmitKeyed("key", func(_ context.Context) error {
+		return nil
+	})
+	assert.NotSame(t, running, queued, "Pool.SubmitKeyed(key running) -> new task queued")
+	assert.True(t, queued.Cancel(), "TaskHandle.Cancel(queued) -> true")
+	// a cancelled task is skipped, so the same key is queued again
+	requeued := p.SubmitKeyed("key", func(_ context.Context) error {
+		return nil
+	})
+	assert.NotSame(t, queued, requeued, "Pool.SubmitKeyed(key cancelled) -> new task queued")
+	assert.Equal(t, 1, p.Stats().Queued, "Pool.Stats.Queued -> 1 without the cancelled task")
+	close(release)
+	assert.NoError(t, running.Wait(), "TaskHandle.Wait(running) -> err == nil")
+	assert.NoError(t, requeued.Wait(), "TaskHandle.Wait(requeued) -> err == nil")
+	assert.ErrorIs(t, queued.Wait(), ErrTaskCancelled, "TaskHandle.Wait(cancelled) -> ErrTaskCancelled")
+}
+
+func TestDedupQueue_Full(t *testing.T) {
+	q := newDedupQueue(make(probe.WorkChanQueue, 1), DedupReplace)
+	h := newTaskHandle(1)
+	work := func(h *TaskHandle) probe.Work {
+		return probe.Work{Runner: func() {}}
+	}
+	assert.Same(t, h, q.PushKeyed("a", h, work), "dedupQueue.PushKeyed -> h")
+	// duplicates do not take a slot, so pushing into a full queue does not block
+	assert.Same(t, h, q.PushKeyed("a", newTaskHandle(2), work), "dedupQueue.PushKeyed(duplicate) -> queued handle")
+	assert.Equal(t, 1, q.Len(), "dedupQueue.Len -> 1")
+	pushed := make(chan struct{})
+	go func() {
+		q.PushKeyed("b", newTaskHandle(3), work)
+		close(pushed)
+	}()
+	select {
+	case <-pushed:
+		assert.Fail(t, "dedupQueue.PushKeyed(full) -> blocks")
+	case <-time.After(20 * time.Millisecond):
+	}
+	_, ok := q.Pop(context.Background())
+	assert.True(t, ok, "dedupQueue.Pop -> ok")
+	<-pushed
+	assert.Equal(t, 1, q.Len(), "dedupQueue.Len -> 1")
+}
+
+func TestDedupQueue_CancelQueued(t *testing.T) {
+	q := newDedupQueue(newDeadlineQueue(1, func(_ time.Time) {}), DedupDrop)
+	h := newTaskHandle(1)
+	work := func(h *TaskHandle) probe.Work {
+		return probe.Work{Runner: func() {}}
+	}
+	q.PushKeyed("a", h, work)
+	assert.True(t, h.Cancel(), "Cancel(queued) -> true")
+	assert.Equal(t, 0, q.Len(), "Cancel(queued) -> q.Len == 0")
+	assert.Empty(t, q.keys, "Cancel(queued) -> key removed from index")
+	// the slot of the cancelled task is free
+	assert.NotSame(t, h, q.PushKeyed("a", newTaskHandle(2), work), "dedupQueue.PushKeyed(cancelled key) -> new handle")
+}
+
+func TestPool_SubmitKeyedScheduling(t *testing.T) {
+	cases := []struct {
+		cfg *PoolConfig
+		msg string
+	}{
+		{
+			cfg: &PoolConfig{Queue: QueueRing},
+			msg: "Dedup + QueueRing",
+		},
+		{
+			cfg: &PoolConfig{Scheduling: SchedulingEDF},
+			msg: "Dedup + SchedulingEDF",
+		},
+		{
+			cfg: &PoolConfig{Scheduling: SchedulingWorkStealing},
+			msg: "Dedup + SchedulingWorkStealing",
+		},
+	}
+	for _, c := range cases {
+		c.cfg.LogHandler = logHandler
+		c.cfg.Size = 1
+		c.cfg.Dedup = DedupReplace
+		p := NewPool(c.cfg)
+		p.Pause()
+		ran := make(chan string, 2)
+		first := p.SubmitKeyed("a", func(_ context.Context) error {
+			ran <- "a1"
+			return nil
+		})
+		second := p.SubmitKeyed("a", func(_ context.Context) error {
+			ran <- "a2"
+			return nil
+		})
+		assert.Same(t, first, second, c.msg+" -> shared handle")
+		assert.Equal(t, 1, p.Stats().Queued, c.msg+" -> one queued task")
+		p.Resume()
+		assert.NoError(t, first.Wait(), c.msg+" -> err == nil")
+		assert.Equal(t, "a2", <-ran, c.msg+" -> replacement runs")
+		p.Stop(true)
+		assert.Empty(t, ran, c.msg+" -> task runs once")
+	}
+}
+
+func TestPool_SubmitKeyedEDF(t *testing.T) {
+	p := NewPool(&PoolConfig{
+		LogHandler: logHandler,
+		Size:       1,
+		Scheduling: SchedulingEDF,
+		Dedup:      DedupDrop,
+	})
+	defer p.Stop(true)
+	p.Pause()
+	ran := make(chan string, 4)
+	record := func(name string) func() {
+		return func() {
+			ran <- name
+		}
+	}
+	h := p.SubmitKeyed("a", func(_ context.Context) error {
+		ran <- "keyed"
+		return nil
+	})
+	assert.Same(t, h, p.SubmitKeyed("a", func(_ context.Context) error {
+		ran <- "dropped"
+		return nil
+	}), "Pool.SubmitKeyed(EDF, duplicate) -> queued handle")
+	now := time.Now()
+	p.RunWithDeadline(record("late"), now.Add(time.Hour))
+	p.RunWithDeadline(record("early"), now.Add(time.Minute))
+	p.Resume()
+	assert.NoError(t, h.Wait(), "TaskHandle.Wait -> err == nil")
+	// keyed tasks have no deadline, so EDF runs them after all tasks with a deadline
+	assert.Equal(t, []string{"early", "late", "keyed"}, []string{<-ran, <-ran, <-ran},
+		"Pool.SubmitKeyed(EDF) -> deadline order kept")
+}
@@ -0,0 +1,96 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+type (
+	// Handler is the function of a named task registered in a TaskRegistry. It receives the payload of the
+	// Descriptor the task was submitted with.
+	Handler func(ctx context.Context, payload []byte) error
+
+	// Descriptor is a serializable description of a task: the name of a registered Handler and its payload.
+	// Unlike a Task closure, a Descriptor can be persisted or sent to another process.
+	Descriptor struct {
+		Name    string `json:"name"`    // Name is the name of the Handler.
+		Payload []byte `json:"payload"` // Payload is passed to the Handler.
+	}
+
+	// DescriptorSubmitter submits tasks described by Descriptors. It is implemented by Pool and RemotePool.
+	DescriptorSubmitter interface {
+		SubmitDescriptor(d Descriptor) (*TaskHandle, error)
+	}
+
+	// TaskRegistry maps task names to Handlers. A TaskRegistry is safe for concurrent use.
+	TaskRegistry struct {
+		mu       sync.RWMutex
+		handlers map[string]Handler
+	}
+)
+
+var (
+	ErrUnknownTask    = errors.New("pool: unknown task")            // ErrUnknownTask is the error of unregistered tasks.
+	ErrTaskRegistered = errors.New("pool: task already registered") // ErrTaskRegistered is the error of duplicate names.
+)
+
+// NewTaskRegistry initializes and returns a new empty TaskRegistry.
+func NewTaskRegistry() *TaskRegistry {
+	return &TaskRegistry{handlers: map[string]Handler{}}
+}
+
+// Register registers h under name. Register returns ErrTaskRegistered if name is already registered, and
+// ErrUnknownTask if name is empty.
+func (r *TaskRegistry) Register(name string, h Handler) error {
+	if name == "" {
+		return fmt.Errorf("%w: empty name", ErrUnknownTask)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.handlers[name]; ok {
+		return fmt.Errorf("%w: %q", ErrTaskRegistered, name)
+	}
+	r.handlers[name] = h
+	return nil
+}
+
+// Lookup returns the Handler registered under name. Lookup on a nil TaskRegistry returns false.
+func (r *TaskRegistry) Lookup(name string) (Handler, bool) {
+	if r == nil {
+		return nil, false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	h, ok := r.handlers[name]
+	return h, ok
+}
+
+// Names returns the names of all registered Handlers, sorted.
+func (r *TaskRegistry) Names() []string {
+	if r == nil {
+		return nil
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.handlers))
+	for name := range r.handlers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Task resolves d to a Task that calls its Handler with its payload. Task returns ErrUnknownTask if no
+// Handler is registered under the name of d.
+func (r *TaskRegistry) Task(d Descriptor) (Task, error) {
+	h, ok := r.Lookup(d.Name)
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownTask, d.Name)
+	}
+	return func(ctx context.Context) error {
+		return h(ctx, d.Payload)
+	}, nil
+}
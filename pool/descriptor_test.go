@@ -0,0 +1,76 @@
+package pool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTaskRegistry(t *testing.T) {
+	r := NewTaskRegistry()
+	var got []byte
+	h := func(_ context.Context, payload []byte) error {
+		got = payload
+		return nil
+	}
+	assert.NoError(t, r.Register("b", h), "TaskRegistry.Register(b) -> err == nil")
+	assert.NoError(t, r.Register("a", h), "TaskRegistry.Register(a) -> err == nil")
+	assert.ErrorIs(t, r.Register("a", h), ErrTaskRegistered, "TaskRegistry.Register(duplicate) -> ErrTaskRegistered")
+	assert.ErrorIs(t, r.Register("", h), ErrUnknownTask, "TaskRegistry.Register(empty) -> ErrUnknownTask")
+	assert.Equal(t, []string{"a", "b"}, r.Names(), "TaskRegistry.Names -> sorted names")
+	_, ok := r.Lookup("a")
+	assert.True(t, ok, "TaskRegistry.Lookup(a) -> ok")
+	_, ok = r.Lookup("c")
+	assert.False(t, ok, "TaskRegistry.Lookup(c) -> !ok")
+	task, err := r.Task(Descriptor{Name: "a", Payload: []byte("payload")})
+	assert.NoError(t, err, "TaskRegistry.Task(a) -> err == nil")
+	assert.NoError(t, task(context.Background()), "Task -> err == nil")
+	assert.Equal(t, []byte("payload"), got, "Task -> Handler called with payload")
+	_, err = r.Task(Descriptor{Name: "c"})
+	assert.ErrorIs(t, err, ErrUnknownTask, "TaskRegistry.Task(c) -> ErrUnknownTask")
+	var nilRegistry *TaskRegistry
+	_, err = nilRegistry.Task(Descriptor{Name: "a"})
+	assert.ErrorIs(t, err, ErrUnknownTask, "TaskRegistry(nil).Task -> ErrUnknownTask")
+	assert.Empty(t, nilRegistry.Names(), "TaskRegistry(nil).Names -> empty")
+}
+
+func TestDescriptor_JSON(t *testing.T) {
+	d := Descriptor{Name: "resize-image", Payload: []byte{0, 1, 2}}
+	b, err := json.Marshal(d)
+	assert.NoError(t, err, "json.Marshal -> err == nil")
+	assert.JSONEq(t, `{"name":"resize-image","payload":"AAEC"}`, string(b), "json.Marshal -> name and base64 payload")
+	var decoded Descriptor
+	assert.NoError(t, json.Unmarshal(b, &decoded), "json.Unmarshal -> err == nil")
+	assert.Equal(t, d, decoded, "json.Unmarshal -> round trip")
+}
+
+func TestPool_SubmitDescriptor(t *testing.T) {
+	errBoom := errors.New("boom")
+	tasks := NewTaskRegistry()
+	tasks.Register("fail", func(_ context.Context, payload []byte) error {
+		return errors.Join(errBoom, errors.New(string(payload)))
+	})
+	p := NewPool(&PoolConfig{
+		LogHandler: logHandler,
+		Size:       1,
+		Tasks:      tasks,
+	})
+	defer p.Stop(true)
+	h, err := p.SubmitDescriptor(Descriptor{Name: "fail", Payload: []byte("payload")})
+	assert.NoError(t, err, "Pool.SubmitDescriptor(fail) -> err == nil")
+	assert.ErrorIs(t, h.Wait(), errBoom, "TaskHandle.Wait -> Handler error")
+	assert.ErrorContains(t, h.Wait(), "payload", "TaskHandle.Wait -> Handler called with payload")
+	failures := p.Failures()
+	if assert.Len(t, failures, 1, "Pool.Failures -> 1 failure") {
+		assert.Equal(t, "fail", failures[0].Name, "Failure.Name -> task name")
+	}
+	_, err = p.SubmitDescriptor(Descriptor{Name: "missing"})
+	assert.ErrorIs(t, err, ErrUnknownTask, "Pool.SubmitDescriptor(missing) -> ErrUnknownTask")
+	unregistered := NewPool(&PoolConfig{LogHandler: logHandler, Size: 1})
+	defer unregistered.Stop(true)
+	_, err = unregistered.SubmitDescriptor(Descriptor{Name: "fail"})
+	assert.ErrorIs(t, err, ErrUnknownTask, "Pool.SubmitDescriptor(no registry) -> ErrUnknownTask")
+}
@@ -0,0 +1,303 @@
+package pool
+
+import (
+	"cmp"
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+
+	"github.com/amplify-security/probe"
+)
+
+type (
+	// DurablePool is a Pool whose tasks survive crashes and restarts. Every Descriptor enqueued with Enqueue
+	// is appended to a journal file and flushed to stable storage before Enqueue returns. A task is
+	// acknowledged in the journal once it finishes, and tasks that were never acknowledged are replayed when
+	// the journal is opened again. Tasks are delivered at least once, so handlers should be idempotent.
+	DurablePool struct {
+		*Pool
+		queue *durableQueue
+	}
+
+	// durableEntry is a task or Work waiting in a durableQueue. Work is set if the entry was pushed with Run
+	// and is not journaled.
+	durableEntry struct {
+		id      uint64
+		name    string
+		payload []byte
+		work    probe.Work
+		elem    *list.Element // elem is the element of the entry while it is queued, set by PushTask.
+	}
+
+	// durableQueue is the unbounded shared work queue of a DurablePool, backed by a journal.
+	durableQueue struct {
+		log       *slog.Logger
+		mu        sync.Mutex
+		journal   *journal
+		closed    bool
+		nextID    uint64
+		live      map[uint64]*durableEntry
+		dead      int
+		threshold int
+		entries   *list.List
+		notify    chan struct{}
+		maxRecord int // maxRecord is the maximum size of the body of a journal record.
+		run       func(e *durableEntry) probe.Work
+	}
+)
+
+var (
+	ErrDurableClosed   = errors.New("pool: durable pool closed")    // ErrDurableClosed is returned by Enqueue after Close.
+	ErrPayloadTooLarge = errors.New("pool: task payload too large") // ErrPayloadTooLarge is returned by Enqueue for tasks too large to journal.
+)
+
+// NewDurablePool opens the journal, initializes and returns a new DurablePool. Tasks that were enqueued but
+// never acknowledged are queued again in their original order before the DurablePool starts.
+func NewDurablePool(cfg *DurablePoolConfig) (*DurablePool, error) {
+	poolCfg := cfg.getPool()
+	p := &DurablePool{
+		Pool: newPool(&poolCfg),
+	}
+	q, err := openDurableQueue(cfg.Path, cfg.getCompactThreshold(), p.log)
+	if err != nil {
+		return nil, fmt.Errorf("pool: failed to open journal: %w", err)
+	}
+	q.run = p.work
+	p.queue = q
+	p.Pool.queue = q
+	if n := q.Len(); n > 0 {
+		p.log.Info("replaying journal", "tasks", n)
+	}
+	p.Start()
+	return p, nil
+}
+
+// Enqueue journals a task described by d and queues it. Enqueue returns once the task is flushed to stable
+// storage. Enqueue returns ErrUnknownTask if no Handler is registered under the name of d in the
+// PoolConfig.Tasks registry, and ErrPayloadTooLarge if the name and payload of d exceed 1 GiB.
+func (p *DurablePool) Enqueue(d Descriptor) error {
+	if _, ok := p.tasks.Lookup(d.Name); !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownTask, d.Name)
+	}
+	return p.queue.enqueue(d.Name, d.Payload)
+}
+
+// Close stops the DurablePool, waits for running tasks to finish and closes the journal. Tasks that are
+// still queued remain in the journal and are replayed by the next DurablePool opened on it.
+func (p *DurablePool) Close() error {
+	p.Stop(true)
+	return p.queue.close()
+}
+
+// work returns the Work that runs the Handler of a journaled task and acknowledges it. A task whose
+// context is done when its Handler fails, because the DurablePool was stopped or the task was cancelled,
+// is not acknowledged and is replayed by the next DurablePool opened on the journal.
+func (p *DurablePool) work(e *durableEntry) probe.Work {
+	h := p.newTaskHandle()
+	return p.taskWork(h, e.name, nil, func(ctx context.Context) error {
+		t, err := p.tasks.Task(Descriptor{Name: e.name, Payload: e.payload})
+		if err != nil {
+			p.queue.ack(e.id)
+			return err
+		}
+		err = CallTask(ctx, t)
+		if err != nil && ctx.Err() != nil {
+			return err
+		}
+		p.queue.ack(e.id)
+		return err
+	})
+}
+
+// openDurableQueue opens the journal at path and queues all entries that were not acknowledged. The
+// journal is compacted to these entries.
+func openDurableQueue(path string, threshold int, log *slog.Logger) (*durableQueue, error) {
+	j, records, err := openJournal(path)
+	if err != nil {
+		return nil, err
+	}
+	q := &durableQueue{
+		log:       log,
+		journal:   j,
+		live:      map[uint64]*durableEntry{},
+		threshold: threshold,
+		maxRecord: journalMaxRecord,
+		entries:   list.New(),
+		notify:    make(chan struct{}, 1),
+	}
+	for _, r := range records {
+		q.nextID = max(q.nextID, r.id)
+		switch r.op {
+		case journalEnqueue:
+			q.live[r.id] = &durableEntry{id: r.id, name: r.name, payload: r.payload}
+		case journalAck:
+			delete(q.live, r.id)
+		}
+	}
+	ids := make([]uint64, 0, len(q.live))
+	for id := range q.live {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	for _, id := range ids {
+		q.entries.PushBack(q.live[id])
+	}
+	if err := q.compactLocked(); err != nil {
+		j.close()
+		return nil, err
+	}
+	return q, nil
+}
+
+// enqueue journals and queues a task.
+func (q *durableQueue) enqueue(name string, payload []byte) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return ErrDurableClosed
+	}
+	e := &durableEntry{
+		id:      q.nextID + 1,
+		name:    name,
+		payload: payload,
+	}
+	record := journalRecord{op: journalEnqueue, id: e.id, name: name, payload: payload}
+	if size := record.size(); size > q.maxRecord {
+		// replay would stop at the oversize record and drop all later tasks
+		return fmt.Errorf("%w: %d bytes", ErrPayloadTooLarge, size)
+	}
+	if err := q.journal.append(record, true); err != nil {
+		return fmt.Errorf("pool: failed to journal task: %w", err)
+	}
+	q.nextID = e.id
+	q.live[e.id] = e
+	q.entries.PushBack(e)
+	q.signal()
+	return nil
+}
+
+// ack acknowledges a journaled task, compacting the journal once enough tasks are acknowledged. Acks are
+// not flushed to stable storage, a task whose ack is lost in a crash is replayed.
+func (q *durableQueue) ack(id uint64) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if _, ok := q.live[id]; !ok || q.closed {
+		return
+	}
+	delete(q.live, id)
+	if err := q.journal.append(journalRecord{op: journalAck, id: id}, false); err != nil {
+		q.log.Warn("failed to acknowledge task in journal", "error", err)
+		return
+	}
+	// the enqueue and ack records of the task are no longer needed
+	q.dead += 2
+	if q.dead >= q.threshold && q.dead > len(q.live) {
+		if err := q.compactLocked(); err != nil {
+			q.log.Warn("failed to compact journal", "error", err)
+		}
+	}
+}
+
+// compactLocked rewrites the journal with the enqueue records of all tasks that are not acknowledged. The
+// caller must hold q.mu.
+func (q *durableQueue) compactLocked() error {
+	records := make([]journalRecord, 0, len(q.live))
+	for _, e := range q.live {
+		records = append(records, journalRecord{op: journalEnqueue, id: e.id, name: e.name, payload: e.payload})
+	}
+	slices.SortFunc(records, func(a, b journalRecord) int {
+		return cmp.Compare(a.id, b.id)
+	})
+	if err := q.journal.rewrite(records); err != nil {
+		return err
+	}
+	q.dead = 0
+	return nil
+}
+
+// close closes the journal. Later acks are ignored.
+func (q *durableQueue) close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return nil
+	}
+	q.closed = true
+	return q.journal.close()
+}
+
+// signal wakes up a Probe waiting in Pop.
+func (q *durableQueue) signal() {
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+}
+
+// Push implementation of probe.Queue for durableQueue. Work pushed with Push is not journaled.
+func (q *durableQueue) Push(w probe.Work) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.entries.PushBack(&durableEntry{work: w})
+	q.signal()
+}
+
+// PushTask implementation of taskQueue for durableQueue. Like Push, the Task is not journaled.
+func (q *durableQueue) PushTask(h *TaskHandle, w probe.Work) {
+	q.mu.Lock()
+	e := &durableEntry{work: w}
+	e.elem = q.entries.PushBack(e)
+	q.signal()
+	q.mu.Unlock()
+	h.setRemove(func() bool {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		if e.elem == nil {
+			return false
+		}
+		q.entries.Remove(e.elem)
+		e.elem = nil
+		return true
+	})
+}
+
+// Pop implementation of probe.Queue for durableQueue.
+func (q *durableQueue) Pop(ctx context.Context) (probe.Work, bool) {
+	for {
+		if ctx.Err() != nil {
+			// a stopping Probe leaves the remaining entries journaled
+			return probe.Work{}, false
+		}
+		q.mu.Lock()
+		if front := q.entries.Front(); front != nil {
+			e := q.entries.Remove(front).(*durableEntry)
+			e.elem = nil
+			if q.entries.Len() > 0 {
+				// pass the wake up on to the next Probe
+				q.signal()
+			}
+			q.mu.Unlock()
+			if e.work.Runner != nil {
+				return e.work, true
+			}
+			return q.run(e), true
+		}
+		q.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			return probe.Work{}, false
+		case <-q.notify:
+		}
+	}
+}
+
+// Len implementation of probe.Queue for durableQueue.
+func (q *durableQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.entries.Len()
+}
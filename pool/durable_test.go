@@ -0,0 +1,204 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// durableRecorder records the payloads of tasks run by a DurablePool.
+type durableRecorder struct {
+	mu       sync.Mutex
+	payloads []string
+	done     chan struct{}
+	expected int
+}
+
+// newDurableRecorder initializes and returns a new durableRecorder that closes done after expected tasks.
+func newDurableRecorder(expected int) *durableRecorder {
+	return &durableRecorder{done: make(chan struct{}), expected: expected}
+}
+
+// handle is a Handler that records the payload.
+func (r *durableRecorder) handle(_ context.Context, payload []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.payloads = append(r.payloads, string(payload))
+	if len(r.payloads) == r.expected {
+		close(r.done)
+	}
+	return nil
+}
+
+// registry returns a new TaskRegistry with h registered under name.
+func registry(name string, h Handler) *TaskRegistry {
+	r := NewTaskRegistry()
+	r.Register(name, h)
+	return r
+}
+
+func TestDurablePool(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal")
+	rec := newDurableRecorder(3)
+	p, err := NewDurablePool(&DurablePoolConfig{
+		Pool: &PoolConfig{LogHandler: logHandler, Size: 1, Tasks: registry("record", rec.handle)},
+		Path: path,
+	})
+	assert.NoError(t, err, "NewDurablePool -> err == nil")
+	for _, payload := range []string{"a", "b", "c"} {
+		assert.NoError(t, p.Enqueue(Descriptor{Name: "record", Payload: []byte(payload)}), "DurablePool.Enqueue -> err == nil")
+	}
+	assert.ErrorIs(t, p.Enqueue(Descriptor{Name: "missing"}), ErrUnknownTask, "DurablePool.Enqueue(missing) -> ErrUnknownTask")
+	<-rec.done
+	assert.Equal(t, []string{"a", "b", "c"}, rec.payloads, "DurablePool -> tasks run in order")
+	assert.NoError(t, p.Close(), "DurablePool.Close -> err == nil")
+	assert.ErrorIs(t, p.Enqueue(Descriptor{Name: "record"}), ErrDurableClosed, "DurablePool.Enqueue(closed) -> ErrDurableClosed")
+	p, err = NewDurablePool(&DurablePoolConfig{
+		Pool: &PoolConfig{LogHandler: logHandler, Size: 1, Tasks: registry("record", rec.handle)},
+		Path: path,
+	})
+	assert.NoError(t, err, "NewDurablePool(reopen) -> err == nil")
+	assert.Equal(t, 0, p.Stats().Queued, "NewDurablePool(all acknowledged) -> nothing replayed")
+	assert.NoError(t, p.Close(), "DurablePool.Close -> err == nil")
+}
+
+func TestDurablePool_PayloadTooLarge(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal")
+	rec := newDurableRecorder(1)
+	p, err := NewDurablePool(&DurablePoolConfig{
+		Pool: &PoolConfig{LogHandler: logHandler, Size: 1, Tasks: registry("record", rec.handle)},
+		Path: path,
+	})
+	assert.NoError(t, err, "NewDurablePool -> err == nil")
+	p.queue.maxRecord = 32
+	err = p.Enqueue(Descriptor{Name: "record", Payload: make([]byte, 32)})
+	assert.ErrorIs(t, err, ErrPayloadTooLarge, "DurablePool.Enqueue(oversize) -> ErrPayloadTooLarge")
+	info, err := os.Stat(path)
+	assert.NoError(t, err, "os.Stat(journal) -> err == nil")
+	assert.Equal(t, int64(0), info.Size(), "DurablePool.Enqueue(oversize) -> nothing journaled")
+	assert.NoError(t, p.Enqueue(Descriptor{Name: "record", Payload: []byte("a")}), "DurablePool.Enqueue -> err == nil")
+	<-rec.done
+	assert.NoError(t, p.Close(), "DurablePool.Close -> err == nil")
+}
+
+func TestDurablePool_CancelQueued(t *testing.T) {
+	p, err := NewDurablePool(&DurablePoolConfig{
+		Pool: &PoolConfig{LogHandler: logHandler, Size: 1},
+		Path: filepath.Join(t.TempDir(), "journal"),
+	})
+	assert.NoError(t, err, "NewDurablePool -> err == nil")
+	ctrl, started := make(chan struct{}), make(chan struct{})
+	p.Run(func() {
+		close(started)
+		<-ctrl
+	})
+	<-started
+	h := p.Submit(func(_ context.Context) error {
+		t.Error("cancelled task executed")
+		return nil
+	})
+	assert.Equal(t, 1, p.queue.Len(), "Submit(busy pool) -> queue.Len == 1")
+	assert.True(t, h.Cancel(), "Cancel(queued) -> true")
+	assert.Equal(t, 0, p.queue.Len(), "Cancel(queued) -> queue.Len == 0")
+	assert.Equal(t, int64(0), p.skipCtr.Load(), "Cancel(queued) -> nothing left to skip")
+	close(ctrl)
+	assert.NoError(t, p.Close(), "DurablePool.Close -> err == nil")
+}
+
+func TestDurablePool_Replay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal")
+	started := make(chan struct{})
+	blocking := func(ctx context.Context, _ []byte) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	p, err := NewDurablePool(&DurablePoolConfig{
+		Pool: &PoolConfig{LogHandler: logHandler, Size: 1, Tasks: registry("record", blocking)},
+		Path: path,
+	})
+	assert.NoError(t, err, "NewDurablePool -> err == nil")
+	for _, payload := range []string{"a", "b", "c"} {
+		assert.NoError(t, p.Enqueue(Descriptor{Name: "record", Payload: []byte(payload)}), "DurablePool.Enqueue -> err == nil")
+	}
+	<-started
+	// a is interrupted by Close, b and c are still queued
+	assert.NoError(t, p.Close(), "DurablePool.Close -> err == nil")
+	rec := newDurableRecorder(3)
+	p, err = NewDurablePool(&DurablePoolConfig{
+		Pool: &PoolConfig{LogHandler: logHandler, Size: 1, Tasks: registry("record", rec.handle)},
+		Path: path,
+	})
+	assert.NoError(t, err, "NewDurablePool(replay) -> err == nil")
+	defer p.Close()
+	select {
+	case <-rec.done:
+	case <-time.After(time.Second):
+		assert.Fail(t, "NewDurablePool(replay) -> interrupted and queued tasks replayed")
+	}
+	assert.Equal(t, []string{"a", "b", "c"}, rec.payloads, "NewDurablePool(replay) -> tasks replayed in order")
+}
+
+func TestDurablePool_Failure(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal")
+	errBoom := errors.New("boom")
+	done := make(chan struct{})
+	tasks := registry("fail", func(_ context.Context, _ []byte) error {
+		return errBoom
+	})
+	tasks.Register("panic", func(_ context.Context, _ []byte) error {
+		defer close(done)
+		panic("boom")
+	})
+	p, err := NewDurablePool(&DurablePoolConfig{
+		Pool: &PoolConfig{LogHandler: logHandler, Size: 1, Tasks: tasks},
+		Path: path,
+	})
+	assert.NoError(t, err, "NewDurablePool -> err == nil")
+	assert.NoError(t, p.Enqueue(Descriptor{Name: "fail"}), "DurablePool.Enqueue -> err == nil")
+	assert.NoError(t, p.Enqueue(Descriptor{Name: "panic"}), "DurablePool.Enqueue -> err == nil")
+	<-done
+	assert.NoError(t, p.Close(), "DurablePool.Close -> err == nil")
+	failures := p.Failures()
+	if assert.Len(t, failures, 2, "DurablePool.Failures -> 2 failures") {
+		assert.Equal(t, "fail", failures[0].Name, "Failure.Name -> fail")
+		assert.ErrorIs(t, failures[0].Err, errBoom, "Failure.Err -> errBoom")
+		var panicErr *PanicError
+		assert.ErrorAs(t, failures[1].Err, &panicErr, "Failure.Err -> *PanicError")
+	}
+	p, err = NewDurablePool(&DurablePoolConfig{
+		Pool: &PoolConfig{LogHandler: logHandler, Size: 1},
+		Path: path,
+	})
+	assert.NoError(t, err, "NewDurablePool(reopen) -> err == nil")
+	assert.Equal(t, 0, p.Stats().Queued, "NewDurablePool(failed tasks) -> failed tasks are acknowledged")
+	assert.NoError(t, p.Close(), "DurablePool.Close -> err == nil")
+}
+
+func TestDurablePool_Compact(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal")
+	rec := newDurableRecorder(20)
+	p, err := NewDurablePool(&DurablePoolConfig{
+		Pool:             &PoolConfig{LogHandler: logHandler, Size: 2, Tasks: registry("record", rec.handle)},
+		Path:             path,
+		CompactThreshold: 4,
+	})
+	assert.NoError(t, err, "NewDurablePool -> err == nil")
+	defer p.Close()
+	payload := make([]byte, 1024)
+	for range 20 {
+		err := p.Enqueue(Descriptor{Name: "record", Payload: payload})
+		assert.NoError(t, err, "DurablePool.Enqueue -> err == nil")
+	}
+	<-rec.done
+	time.Sleep(10 * time.Millisecond)
+	info, err := os.Stat(path)
+	assert.NoError(t, err, "os.Stat -> err == nil")
+	assert.Less(t, info.Size(), int64(4*1024), "DurablePool(CompactThreshold: 4) -> journal compacted")
+}
@@ -0,0 +1,57 @@
+package pool
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	failureHistory = 32 // failureHistory is the number of recent failures kept by a Pool.
+)
+
+type (
+	// PanicError is the error of a Task that panicked.
+	PanicError struct {
+		Value any    // Value is the value passed to panic.
+		Stack []byte // Stack is the stack trace of the panicking goroutine.
+	}
+
+	// Failure is a Task that failed with an error or panicked.
+	Failure struct {
+		TaskID uint64            // TaskID is the ID of the Task.
+		Name   string            // Name is the name of the Task.
+		Labels map[string]string // Labels are the labels of the Task.
+		Err    error             // Err is the error of the Task. Panics are reported as a *PanicError.
+		Time   time.Time         // Time is the time the Task failed.
+	}
+
+	// failureLog is a bounded log of the most recent Failures.
+	failureLog struct {
+		mu       sync.Mutex
+		failures []Failure
+	}
+)
+
+// Error implementation of error for PanicError.
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("pool: task panicked: %v", e.Value)
+}
+
+// add records f, discarding the oldest Failure if the log is full.
+func (l *failureLog) add(f Failure) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.failures) == failureHistory {
+		copy(l.failures, l.failures[1:])
+		l.failures = l.failures[:failureHistory-1]
+	}
+	l.failures = append(l.failures, f)
+}
+
+// list returns a copy of the recorded Failures, oldest first.
+func (l *failureLog) list() []Failure {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]Failure(nil), l.failures...)
+}
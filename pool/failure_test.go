@@ -0,0 +1,52 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFailureLog(t *testing.T) {
+	l := new(failureLog)
+	for i := range failureHistory + 2 {
+		l.add(Failure{TaskID: uint64(i)})
+	}
+	failures := l.list()
+	assert.Len(t, failures, failureHistory, "add x failureHistory+2 -> len(list) == failureHistory")
+	assert.Equal(t, uint64(2), failures[0].TaskID, "add x failureHistory+2 -> oldest dropped")
+	assert.Equal(t, uint64(failureHistory+1), failures[failureHistory-1].TaskID, "add x failureHistory+2 -> newest last")
+}
+
+func TestPool_Failures(t *testing.T) {
+	p := NewPool(&PoolConfig{
+		LogHandler: logHandler,
+		Size:       1,
+	})
+	errTest := errors.New("test")
+	failed := p.SubmitNamed("failed", map[string]string{"key": "value"}, func(_ context.Context) error {
+		return errTest
+	})
+	panicked := p.Submit(func(_ context.Context) error {
+		panic("boom")
+	})
+	done := p.Submit(func(_ context.Context) error {
+		return nil
+	})
+	assert.ErrorIs(t, failed.Wait(), errTest, "Submit(error) -> h.Wait == errTest")
+	var panicErr *PanicError
+	assert.ErrorAs(t, panicked.Wait(), &panicErr, "Submit(panic) -> h.Wait == *PanicError")
+	assert.Equal(t, "boom", panicErr.Value, "Submit(panic) -> panicErr.Value == boom")
+	assert.Contains(t, string(panicErr.Stack), "TestPool_Failures", "Submit(panic) -> panicErr.Stack contains Task")
+	assert.Equal(t, TaskFailed, panicked.Status(), "Submit(panic) -> h.Status == TaskFailed")
+	assert.NoError(t, done.Wait(), "Submit -> h.Wait == nil")
+	failures := p.Failures()
+	assert.Len(t, failures, 2, "Submit(error) + Submit(panic) -> len(p.Failures) == 2")
+	assert.Equal(t, failed.ID(), failures[0].TaskID, "Submit(error) -> failures[0].TaskID == h.ID")
+	assert.Equal(t, "failed", failures[0].Name, "Submit(error) -> failures[0].Name == failed")
+	assert.Equal(t, map[string]string{"key": "value"}, failures[0].Labels, "Submit(error) -> failures[0].Labels")
+	assert.ErrorIs(t, failures[0].Err, errTest, "Submit(error) -> failures[0].Err == errTest")
+	assert.ErrorAs(t, failures[1].Err, &panicErr, "Submit(panic) -> failures[1].Err == *PanicError")
+	p.Stop(true)
+}
@@ -0,0 +1,228 @@
+package pool
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+const (
+	journalEnqueue byte = iota + 1 // journalEnqueue records an enqueued entry.
+	journalAck                     // journalAck records the acknowledgement of an entry.
+)
+
+const (
+	journalHeaderSize = 8       // journalHeaderSize is the size of the length and checksum of a record.
+	journalMaxRecord  = 1 << 30 // journalMaxRecord is the maximum size of a record body.
+)
+
+type (
+	// journalRecord is a single record of a journal.
+	journalRecord struct {
+		op      byte
+		id      uint64
+		name    string
+		payload []byte
+	}
+
+	// journal is an append-only log of journalRecords. Every record is framed by its length and a CRC-32
+	// checksum, so a record torn by a crash is detected and truncated when the journal is opened.
+	journal struct {
+		path string
+		file *os.File
+	}
+)
+
+var (
+	errJournalCorrupt = errors.New("pool: corrupt journal record") // errJournalCorrupt is the error of an invalid record.
+)
+
+// openJournal opens or creates the journal at path and returns all of its records. A torn or corrupt
+// record and everything after it is truncated.
+func openJournal(path string) (*journal, []journalRecord, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, nil, err
+	}
+	var records []journalRecord
+	var offset int64
+	r := bufio.NewReader(file)
+	for {
+		record, n, err := readJournalRecord(r)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, errJournalCorrupt) {
+			// the tail of the journal was torn by a crash, drop it
+			if err := file.Truncate(offset); err != nil {
+				file.Close()
+				return nil, nil, err
+			}
+			break
+		}
+		if err != nil {
+			file.Close()
+			return nil, nil, err
+		}
+		records = append(records, record)
+		offset += n
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+	return &journal{path: path, file: file}, records, nil
+}
+
+// readJournalRecord reads the next record from r and returns it with its size.
+func readJournalRecord(r io.Reader) (journalRecord, int64, error) {
+	var header [journalHeaderSize]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return journalRecord{}, 0, err
+	}
+	size := binary.BigEndian.Uint32(header[:4])
+	if size == 0 || size > journalMaxRecord {
+		return journalRecord{}, 0, errJournalCorrupt
+	}
+	body := make([]byte, size)
+	if _, err := io.ReadFull(r, body); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return journalRecord{}, 0, err
+	}
+	if crc32.ChecksumIEEE(body) != binary.BigEndian.Uint32(header[4:]) {
+		return journalRecord{}, 0, errJournalCorrupt
+	}
+	record, err := decodeJournalRecord(body)
+	if err != nil {
+		return journalRecord{}, 0, err
+	}
+	return record, journalHeaderSize + int64(size), nil
+}
+
+// decodeJournalRecord decodes the body of a record.
+func decodeJournalRecord(body []byte) (journalRecord, error) {
+	record := journalRecord{op: body[0]}
+	id, n := binary.Uvarint(body[1:])
+	if n <= 0 {
+		return journalRecord{}, errJournalCorrupt
+	}
+	record.id = id
+	rest := body[1+n:]
+	switch record.op {
+	case journalAck:
+		return record, nil
+	case journalEnqueue:
+		size, n := binary.Uvarint(rest)
+		if n <= 0 || uint64(len(rest)-n) < size {
+			return journalRecord{}, errJournalCorrupt
+		}
+		record.name = string(rest[n : n+int(size)])
+		record.payload = rest[n+int(size):]
+		return record, nil
+	default:
+		return journalRecord{}, fmt.Errorf("%w: unknown op %d", errJournalCorrupt, record.op)
+	}
+}
+
+// size returns the size of the body of the record.
+func (r journalRecord) size() int {
+	n := 1 + uvarintLen(r.id)
+	if r.op == journalEnqueue {
+		n += uvarintLen(uint64(len(r.name))) + len(r.name) + len(r.payload)
+	}
+	return n
+}
+
+// uvarintLen returns the number of bytes binary.AppendUvarint appends for x.
+func uvarintLen(x uint64) int {
+	n := 1
+	for ; x >= 0x80; x >>= 7 {
+		n++
+	}
+	return n
+}
+
+// encode appends the framed record to b.
+func (r journalRecord) encode(b []byte) []byte {
+	start := len(b)
+	b = append(b, make([]byte, journalHeaderSize)...)
+	b = append(b, r.op)
+	b = binary.AppendUvarint(b, r.id)
+	if r.op == journalEnqueue {
+		b = binary.AppendUvarint(b, uint64(len(r.name)))
+		b = append(b, r.name...)
+		b = append(b, r.payload...)
+	}
+	body := b[start+journalHeaderSize:]
+	binary.BigEndian.PutUint32(b[start:], uint32(len(body)))
+	binary.BigEndian.PutUint32(b[start+4:], crc32.ChecksumIEEE(body))
+	return b
+}
+
+// append writes a record to the end of the journal, and flushes it to stable storage if sync is true.
+func (j *journal) append(r journalRecord, sync bool) error {
+	if _, err := j.file.Write(r.encode(nil)); err != nil {
+		return err
+	}
+	if sync {
+		return j.file.Sync()
+	}
+	return nil
+}
+
+// rewrite atomically replaces the contents of the journal with records.
+func (j *journal) rewrite(records []journalRecord) error {
+	tmp, err := os.CreateTemp(filepath.Dir(j.path), filepath.Base(j.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	var b []byte
+	for _, r := range records {
+		b = r.encode(b)
+	}
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), j.path); err != nil {
+		return err
+	}
+	syncDir(filepath.Dir(j.path))
+	file, err := os.OpenFile(j.path, os.O_RDWR|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	j.file.Close()
+	j.file = file
+	return nil
+}
+
+// close closes the journal file.
+func (j *journal) close() error {
+	return j.file.Close()
+}
+
+// syncDir flushes the directory entries of dir to stable storage where the platform supports it.
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	d.Sync()
+	d.Close()
+}
@@ -0,0 +1,181 @@
+package pool
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJournal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal")
+	j, records, err := openJournal(path)
+	assert.NoError(t, err, "openJournal(new) -> err == nil")
+	assert.Empty(t, records, "openJournal(new) -> no records")
+	written := []journalRecord{
+		{op: journalEnqueue, id: 1, name: "a", payload: []byte("payload")},
+		{op: journalEnqueue, id: 300, name: "bb", payload: []byte{}},
+		{op: journalAck, id: 1},
+	}
+	for _, r := range written {
+		assert.NoError(t, j.append(r, true), "journal.append -> err == nil")
+	}
+	assert.NoError(t, j.close(), "journal.close -> err == nil")
+	j, records, err = openJournal(path)
+	assert.NoError(t, err, "openJournal -> err == nil")
+	assert.Equal(t, written, records, "openJournal -> written records")
+	assert.NoError(t, j.rewrite(written[1:2]), "journal.rewrite -> err == nil")
+	assert.NoError(t, j.append(written[2], false), "journal.append(after rewrite) -> err == nil")
+	j.close()
+	_, records, err = openJournal(path)
+	assert.NoError(t, err, "openJournal(rewritten) -> err == nil")
+	assert.Equal(t, []journalRecord{written[1], written[2]}, records, "openJournal(rewritten) -> rewritten records")
+	matches, _ := filepath.Glob(path + ".*.tmp")
+	assert.Empty(t, matches, "journal.rewrite -> no temporary files left")
+}
+
+func TestJournal_Torn(t *testing.T) {
+	cases := []struct {
+		corrupt func(b []byte, good int) []byte
+		msg     string
+	}{
+		{
+			corrupt: func(b []byte, _ int) []byte {
+				return b[:len(b)-3]
+			},
+			msg: "torn record -> truncated",
+		},
+		{
+			corrupt: func(b []byte, _ int) []byte {
+				b[len(b)-1] ^= 0xff
+				return b
+			},
+			msg: "checksum mismatch -> truncated",
+		},
+		{
+			corrupt: func(b []byte, good int) []byte {
+				return b[:good+3]
+			},
+			msg: "torn header -> truncated",
+		},
+		{
+			corrupt: func(b []byte, good int) []byte {
+				return b[:good+journalHeaderSize+2]
+			},
+			msg: "torn body -> truncated",
+		},
+		{
+			corrupt: func(b []byte, good int) []byte {
+				clear(b[good : good+4])
+				return b
+			},
+			msg: "empty record -> truncated",
+		},
+		{
+			corrupt: func(b []byte, good int) []byte {
+				return journalRecord{op: 0xff, id: 2}.encode(b[:good])
+			},
+			msg: "unknown op -> truncated",
+		},
+	}
+	for _, c := range cases {
+		path := filepath.Join(t.TempDir(), "journal")
+		first := journalRecord{op: journalEnqueue, id: 1, name: "a", payload: []byte("first")}
+		b := first.encode(nil)
+		good := len(b)
+		b = journalRecord{op: journalEnqueue, id: 2, name: "a", payload: []byte("second")}.encode(b)
+		assert.NoError(t, os.WriteFile(path, c.corrupt(b, good), 0o600), "os.WriteFile -> err == nil")
+		j, records, err := openJournal(path)
+		assert.NoError(t, err, c.msg)
+		assert.Equal(t, []journalRecord{first}, records, c.msg)
+		info, _ := os.Stat(path)
+		assert.Equal(t, int64(good), info.Size(), c.msg)
+		// records appended after truncation are read back
+		assert.NoError(t, j.append(journalRecord{op: journalAck, id: 1}, true), c.msg)
+		j.close()
+		_, records, _ = openJournal(path)
+		assert.Len(t, records, 2, c.msg)
+	}
+}
+
+func TestDecodeJournalRecord(t *testing.T) {
+	cases := []struct {
+		body []byte
+		msg  string
+	}{
+		{
+			body: []byte{journalAck},
+			msg:  "decodeJournalRecord(no id) -> errJournalCorrupt",
+		},
+		{
+			body: []byte{journalEnqueue, 1},
+			msg:  "decodeJournalRecord(no name) -> errJournalCorrupt",
+		},
+		{
+			body: []byte{journalEnqueue, 1, 5, 'a'},
+			msg:  "decodeJournalRecord(short name) -> errJournalCorrupt",
+		},
+		{
+			body: []byte{0xff, 1},
+			msg:  "decodeJournalRecord(unknown op) -> errJournalCorrupt",
+		},
+	}
+	for _, c := range cases {
+		_, err := decodeJournalRecord(c.body)
+		assert.ErrorIs(t, err, errJournalCorrupt, c.msg)
+	}
+}
+
+func TestJournalRecord_size(t *testing.T) {
+	tests := []journalRecord{
+		{op: journalAck, id: 1},
+		{op: journalAck, id: 1 << 40},
+		{op: journalEnqueue, id: 300, name: "a", payload: []byte("payload")},
+		{op: journalEnqueue, id: 2, name: string(make([]byte, 200)), payload: make([]byte, 1000)},
+	}
+	for _, r := range tests {
+		assert.Equal(t, len(r.encode(nil))-journalHeaderSize, r.size(), "journalRecord.size -> encoded body size")
+	}
+}
+
+func TestJournal_Errors(t *testing.T) {
+	dir := t.TempDir()
+	_, _, err := openJournal(filepath.Join(dir, "missing", "journal"))
+	assert.Error(t, err, "openJournal(missing directory) -> err != nil")
+	_, _, err = openJournal(dir)
+	assert.Error(t, err, "openJournal(directory) -> err != nil")
+	sub := filepath.Join(dir, "sub")
+	assert.NoError(t, os.Mkdir(sub, 0o700), "os.Mkdir -> err == nil")
+	j, _, err := openJournal(filepath.Join(sub, "journal"))
+	assert.NoError(t, err, "openJournal -> err == nil")
+	record := journalRecord{op: journalEnqueue, id: 1, name: "a"}
+	assert.NoError(t, j.append(record, true), "journal.append -> err == nil")
+	assert.NoError(t, os.RemoveAll(sub), "os.RemoveAll -> err == nil")
+	assert.Error(t, j.rewrite([]journalRecord{record}), "journal.rewrite(missing directory) -> err != nil")
+	assert.NoError(t, j.close(), "journal.close -> err == nil")
+	assert.Error(t, j.append(record, false), "journal.append(closed) -> err != nil")
+}
+
+func TestJournal_ReopenTruncated(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal")
+	j, _, err := openJournal(path)
+	assert.NoError(t, err, "openJournal -> err == nil")
+	records := []journalRecord{
+		{op: journalEnqueue, id: 1, name: "a", payload: []byte("first")},
+		{op: journalEnqueue, id: 2, name: "a", payload: []byte("second")},
+	}
+	for _, r := range records {
+		assert.NoError(t, j.append(r, true), "journal.append -> err == nil")
+	}
+	j.close()
+	info, _ := os.Stat(path)
+	// a crash in the middle of the last record
+	assert.NoError(t, os.Truncate(path, info.Size()-4), "os.Truncate -> err == nil")
+	for range 2 {
+		j, read, err := openJournal(path)
+		assert.NoError(t, err, "openJournal(truncated mid-record) -> err == nil")
+		assert.Equal(t, records[:1], read, "openJournal(truncated mid-record) -> complete records")
+		j.close()
+	}
+}
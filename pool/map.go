@@ -0,0 +1,183 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"maps"
+	"slices"
+	"sync"
+)
+
+// Map calls fn for every item of items on a Probe in p and returns the outputs in input order. At most
+// MapConfig.MaxInFlight items are submitted to p at once. If any call fails, Map returns the outputs of the
+// successful calls and the errors of the failed calls joined in input order, or only the first error in
+// input order if MapConfig.StopOnError is set. If ctx is done, Map cancels the remaining items and returns
+// the error of ctx. If p is stopped before all items ran, Map cancels the remaining items and the error
+// includes ErrNotRunning. cfg may be nil.
+func Map[In, Out any](ctx context.Context, p *Pool, items []In, fn func(ctx context.Context, in In) (Out, error),
+	cfg *MapConfig) ([]Out, error) {
+	return MapSeq(ctx, p, slices.Values(items), fn, cfg)
+}
+
+// MapSeq is like Map, but calls fn for every item of seq.
+func MapSeq[In, Out any](ctx context.Context, p *Pool, seq iter.Seq[In],
+	fn func(ctx context.Context, in In) (Out, error), cfg *MapConfig) ([]Out, error) {
+	_, outs, err := mapSeq2(ctx, p, withIndex(seq), func(ctx context.Context, _ int, in In) (Out, error) {
+		return fn(ctx, in)
+	}, cfg)
+	return outs, err
+}
+
+// MapEntries is like Map, but calls fn for every key and value of m and returns the outputs by key.
+func MapEntries[K comparable, V, Out any](ctx context.Context, p *Pool, m map[K]V,
+	fn func(ctx context.Context, k K, v V) (Out, error), cfg *MapConfig) (map[K]Out, error) {
+	keys, outs, err := mapSeq2(ctx, p, maps.All(m), fn, cfg)
+	results := make(map[K]Out, len(keys))
+	for i, k := range keys {
+		results[k] = outs[i]
+	}
+	return results, err
+}
+
+// ForEach is like Map, but for functions without output.
+func ForEach[In any](ctx context.Context, p *Pool, items []In, fn func(ctx context.Context, in In) error,
+	cfg *MapConfig) error {
+	return ForEachSeq(ctx, p, slices.Values(items), fn, cfg)
+}
+
+// ForEachSeq is like MapSeq, but for functions without output.
+func ForEachSeq[In any](ctx context.Context, p *Pool, seq iter.Seq[In], fn func(ctx context.Context, in In) error,
+	cfg *MapConfig) error {
+	_, err := MapSeq(ctx, p, seq, func(ctx context.Context, in In) (struct{}, error) {
+		return struct{}{}, fn(ctx, in)
+	}, cfg)
+	return err
+}
+
+// ForEachEntries is like MapEntries, but for functions without output.
+func ForEachEntries[K comparable, V any](ctx context.Context, p *Pool, m map[K]V,
+	fn func(ctx context.Context, k K, v V) error, cfg *MapConfig) error {
+	_, err := MapEntries(ctx, p, m, func(ctx context.Context, k K, v V) (struct{}, error) {
+		return struct{}{}, fn(ctx, k, v)
+	}, cfg)
+	return err
+}
+
+// withIndex returns a sequence of the items of seq paired with their index.
+func withIndex[In any](seq iter.Seq[In]) iter.Seq2[int, In] {
+	return func(yield func(int, In) bool) {
+		i := 0
+		for in := range seq {
+			if !yield(i, in) {
+				return
+			}
+			i++
+		}
+	}
+}
+
+// mapSeq2 submits fn for every key and value of seq to p and returns the keys and outputs in input order.
+func mapSeq2[K, V, Out any](ctx context.Context, p *Pool, seq iter.Seq2[K, V],
+	fn func(ctx context.Context, k K, v V) (Out, error), cfg *MapConfig) ([]K, []Out, error) {
+	if cfg == nil {
+		cfg = &MapConfig{}
+	}
+	mapCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	// items queued on a stopped pool never run, so the map is stopped with the pool
+	poolCtx := p.context()
+	stopPool := context.AfterFunc(poolCtx, cancel)
+	defer stopPool()
+	var (
+		mu         sync.Mutex
+		keys       []K
+		slots      []*Out
+		handles    []*TaskHandle
+		incomplete bool
+	)
+	// cancel all submitted items once the map is stopped, tasks only see the context of the pool
+	stop := context.AfterFunc(mapCtx, func() {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, h := range handles {
+			h.Cancel()
+		}
+	})
+	defer stop()
+	inFlight := make(chan struct{}, cfg.getMaxInFlight(p.size))
+	for k, v := range seq {
+		select {
+		case <-mapCtx.Done():
+		case inFlight <- struct{}{}:
+		}
+		if mapCtx.Err() != nil {
+			incomplete = true
+			break
+		}
+		// each task writes only its own slot, read after its handle is done
+		slot := new(Out)
+		keys = append(keys, k)
+		slots = append(slots, slot)
+		// Submit may block on a full queue, so it must not hold mu which the AfterFunc takes
+		h := p.Submit(func(taskCtx context.Context) error {
+			defer func() {
+				<-inFlight
+			}()
+			ok := false
+			defer func() {
+				// ok is false if fn returned an error or panicked
+				if !ok && cfg.StopOnError {
+					cancel()
+				}
+			}()
+			out, err := fn(taskCtx, k, v)
+			if err != nil {
+				return err
+			}
+			*slot = out
+			ok = true
+			return nil
+		})
+		mu.Lock()
+		handles = append(handles, h)
+		mu.Unlock()
+		if mapCtx.Err() != nil {
+			// the AfterFunc may have run before h was added
+			h.Cancel()
+		}
+	}
+	mu.Lock()
+	submitted := handles
+	mu.Unlock()
+	var errs []error
+	for _, h := range submitted {
+		err := h.Wait()
+		if err == nil {
+			continue
+		}
+		if mapCtx.Err() != nil && (errors.Is(err, ErrTaskCancelled) || errors.Is(err, context.Canceled)) {
+			// the item was cancelled because the map was stopped
+			incomplete = true
+			continue
+		}
+		errs = append(errs, err)
+	}
+	outs := make([]Out, len(slots))
+	for i, slot := range slots {
+		outs[i] = *slot
+	}
+	if incomplete && poolCtx.Err() != nil {
+		errs = append(errs, ErrNotRunning)
+	}
+	switch {
+	case ctx.Err() != nil:
+		return keys, outs, ctx.Err()
+	case len(errs) == 0:
+		return keys, outs, nil
+	case cfg.StopOnError:
+		return keys, outs, errs[0]
+	default:
+		return keys, outs, errors.Join(errs...)
+	}
+}
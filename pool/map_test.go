@@ -0,0 +1,243 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMap(t *testing.T) {
+	p := NewPool(&PoolConfig{
+		LogHandler: logHandler,
+		Size:       4,
+	})
+	inFlight, maxInFlight := new(atomic.Int32), new(atomic.Int32)
+	items := []int{5, 1, 4, 2, 3, 0, 7, 6}
+	outs, err := Map(context.Background(), p, items, func(_ context.Context, in int) (string, error) {
+		n := inFlight.Add(1)
+		for {
+			m := maxInFlight.Load()
+			if n <= m || maxInFlight.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		// later items finish first, results must still be in input order
+		time.Sleep(time.Duration(in) * time.Millisecond)
+		inFlight.Add(-1)
+		return strconv.Itoa(in), nil
+	}, &MapConfig{MaxInFlight: 2})
+	assert.NoError(t, err, "Map -> err == nil")
+	assert.Equal(t, []string{"5", "1", "4", "2", "3", "0", "7", "6"}, outs, "Map -> outputs in input order")
+	assert.LessOrEqual(t, int(maxInFlight.Load()), 2, "Map(MaxInFlight: 2) -> at most 2 in flight")
+	outs, err = Map(context.Background(), p, nil, func(_ context.Context, in int) (string, error) {
+		return strconv.Itoa(in), nil
+	}, nil)
+	assert.NoError(t, err, "Map(nil) -> err == nil")
+	assert.Empty(t, outs, "Map(nil) -> outs == []")
+	p.Stop(true)
+}
+
+func TestMap_FullQueue(t *testing.T) {
+	p := NewPool(&PoolConfig{
+		LogHandler: logHandler,
+		Size:       1,
+		BufferSize: 1,
+	})
+	defer p.Stop(true)
+	items := make([]int, 50)
+	for i := range items {
+		items[i] = i
+	}
+	done := make(chan struct{})
+	var (
+		outs []int
+		err  error
+	)
+	go func() {
+		defer close(done)
+		// more items in flight than the pool can queue, Submit blocks on the full queue
+		outs, err = Map(context.Background(), p, items, func(_ context.Context, in int) (int, error) {
+			time.Sleep(time.Millisecond)
+			return in * 2, nil
+		}, &MapConfig{MaxInFlight: 10})
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Map(MaxInFlight > BufferSize+Size) -> deadlock")
+	}
+	assert.NoError(t, err, "Map(MaxInFlight > BufferSize+Size) -> err == nil")
+	for i, out := range outs {
+		assert.Equal(t, i*2, out, "Map(MaxInFlight > BufferSize+Size) -> outputs in input order")
+	}
+	assert.Len(t, outs, 50, "Map(MaxInFlight > BufferSize+Size) -> all outputs")
+}
+
+func TestMap_Errors(t *testing.T) {
+	p := NewPool(&PoolConfig{
+		LogHandler: logHandler,
+		Size:       2,
+	})
+	err1, err3 := errors.New("1"), errors.New("3")
+	fn := func(_ context.Context, in int) (int, error) {
+		switch in {
+		case 1:
+			return 0, err1
+		case 3:
+			return 0, err3
+		case 4:
+			panic("4")
+		default:
+			return in * 10, nil
+		}
+	}
+	outs, err := Map(context.Background(), p, []int{0, 1, 2, 3, 4}, fn, nil)
+	assert.ErrorIs(t, err, err1, "Map(errors) -> err contains err1")
+	assert.ErrorIs(t, err, err3, "Map(errors) -> err contains err3")
+	var panicErr *PanicError
+	assert.ErrorAs(t, err, &panicErr, "Map(panic) -> err contains *PanicError")
+	assert.Equal(t, []int{0, 0, 20, 0, 0}, outs, "Map(errors) -> outputs of successful items")
+	p.Stop(true)
+}
+
+func TestMap_StopOnError(t *testing.T) {
+	p := NewPool(&PoolConfig{
+		LogHandler: logHandler,
+		Size:       2,
+	})
+	errFail := errors.New("fail")
+	calls := new(atomic.Int32)
+	_, err := Map(context.Background(), p, []int{0, 1, 2, 3, 4, 5, 6, 7}, func(ctx context.Context, in int) (int, error) {
+		calls.Add(1)
+		if in == 0 {
+			return 0, errFail
+		}
+		// block until the map is stopped
+		<-ctx.Done()
+		return 0, ctx.Err()
+	}, &MapConfig{StopOnError: true})
+	assert.ErrorIs(t, err, errFail, "Map(StopOnError) -> err == errFail")
+	assert.Less(t, int(calls.Load()), 8, "Map(StopOnError) -> remaining items not submitted")
+	p.Stop(true)
+}
+
+func TestMap_Context(t *testing.T) {
+	p := NewPool(&PoolConfig{
+		LogHandler: logHandler,
+		Size:       2,
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	started := make(chan struct{}, 8)
+	go func() {
+		<-started
+		cancel()
+	}()
+	_, err := Map(ctx, p, []int{0, 1, 2, 3, 4, 5, 6, 7}, func(taskCtx context.Context, in int) (int, error) {
+		started <- struct{}{}
+		<-taskCtx.Done()
+		return 0, taskCtx.Err()
+	}, nil)
+	assert.ErrorIs(t, err, context.Canceled, "Map(cancelled ctx) -> context.Canceled")
+	p.Stop(true)
+}
+
+func TestMap_PoolStopped(t *testing.T) {
+	p := NewPool(&PoolConfig{
+		LogHandler: logHandler,
+		Size:       1,
+	})
+	started := make(chan struct{}, 8)
+	go func() {
+		<-started
+		p.Stop(false)
+	}()
+	done := make(chan error)
+	go func() {
+		_, err := Map(context.Background(), p, []int{0, 1, 2, 3, 4, 5, 6, 7}, func(taskCtx context.Context, in int) (int, error) {
+			started <- struct{}{}
+			<-taskCtx.Done()
+			return 0, taskCtx.Err()
+		}, &MapConfig{MaxInFlight: 4})
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		assert.ErrorIs(t, err, ErrNotRunning, "Map(pool stopped) -> ErrNotRunning")
+	case <-time.After(time.Second):
+		t.Fatal("Map(pool stopped) -> blocked")
+	}
+	p.awaitDrained()
+	_, err := Map(context.Background(), p, []int{0}, func(_ context.Context, in int) (int, error) {
+		return in, nil
+	}, nil)
+	assert.ErrorIs(t, err, ErrNotRunning, "Map(stopped pool) -> ErrNotRunning")
+}
+
+func TestMapSeq(t *testing.T) {
+	p := NewPool(&PoolConfig{
+		LogHandler: logHandler,
+		Size:       2,
+	})
+	outs, err := MapSeq(context.Background(), p, slices.Values([]string{"a", "b", "c"}), func(_ context.Context, in string) (string, error) {
+		return in + in, nil
+	}, nil)
+	assert.NoError(t, err, "MapSeq -> err == nil")
+	assert.Equal(t, []string{"aa", "bb", "cc"}, outs, "MapSeq -> outputs in input order")
+	ctr := new(atomic.Int32)
+	err = ForEachSeq(context.Background(), p, slices.Values([]int32{1, 2, 3}), func(_ context.Context, in int32) error {
+		ctr.Add(in)
+		return nil
+	}, nil)
+	assert.NoError(t, err, "ForEachSeq -> err == nil")
+	assert.Equal(t, int32(6), ctr.Load(), "ForEachSeq -> fn called for all items")
+	p.Stop(true)
+}
+
+func TestMapEntries(t *testing.T) {
+	p := NewPool(&PoolConfig{
+		LogHandler: logHandler,
+		Size:       2,
+	})
+	m := map[string]int{"a": 1, "b": 2, "c": 3}
+	outs, err := MapEntries(context.Background(), p, m, func(_ context.Context, k string, v int) (string, error) {
+		return k + strconv.Itoa(v), nil
+	}, nil)
+	assert.NoError(t, err, "MapEntries -> err == nil")
+	assert.Equal(t, map[string]string{"a": "a1", "b": "b2", "c": "c3"}, outs, "MapEntries -> outputs by key")
+	seen := make(chan string, len(m))
+	err = ForEachEntries(context.Background(), p, m, func(_ context.Context, k string, _ int) error {
+		seen <- k
+		return nil
+	}, nil)
+	close(seen)
+	assert.NoError(t, err, "ForEachEntries -> err == nil")
+	keys := []string{}
+	for k := range seen {
+		keys = append(keys, k)
+	}
+	assert.ElementsMatch(t, slices.Collect(maps.Keys(m)), keys, "ForEachEntries -> fn called for all keys")
+	p.Stop(true)
+}
+
+func TestForEach(t *testing.T) {
+	p := NewPool(&PoolConfig{
+		LogHandler: logHandler,
+		Size:       2,
+	})
+	errFail := errors.New("fail")
+	err := ForEach(context.Background(), p, []int{0, 1, 2}, func(_ context.Context, in int) error {
+		if in == 1 {
+			return errFail
+		}
+		return nil
+	}, nil)
+	assert.ErrorIs(t, err, errFail, "ForEach(error) -> err == errFail")
+	p.Stop(true)
+}
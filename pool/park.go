@@ -0,0 +1,62 @@
+package pool
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+type (
+	// parking lets goroutines wait for a condition that other goroutines signal, without losing wakeups and
+	// without touching a shared channel while the condition already holds.
+	parking struct {
+		waiting atomic.Int32
+		ch      chan struct{}
+	}
+)
+
+// newParking initializes and returns a new parking for up to n concurrent waiters.
+func newParking(n int) *parking {
+	return &parking{
+		ch: make(chan struct{}, n),
+	}
+}
+
+// wait calls try until it succeeds, parking between attempts. wait returns false if ctx is done first.
+func wait[T any](p *parking, ctx context.Context, try func() (T, bool)) (T, bool) {
+	for {
+		if ctx.Err() != nil {
+			var zero T
+			return zero, false
+		}
+		if v, ok := try(); ok {
+			return v, true
+		}
+		// announce that we are parking before trying again, so a notify after the attempt is guaranteed to
+		// see us and send a signal
+		p.waiting.Add(1)
+		if v, ok := try(); ok {
+			p.waiting.Add(-1)
+			return v, true
+		}
+		select {
+		case <-ctx.Done():
+		case <-p.ch:
+		}
+		p.waiting.Add(-1)
+	}
+}
+
+// notify wakes a parked goroutine, if there are any.
+func (p *parking) notify() {
+	if p.waiting.Load() > 0 {
+		signal(p.ch)
+	}
+}
+
+// signal sends a non-blocking notification on ch.
+func signal(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
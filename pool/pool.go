@@ -3,95 +3,438 @@ package pool
 import (
 	"context"
 	"log/slog"
+	"runtime/debug"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/amplify-security/probe"
 )
 
 type (
+	// Stats is a snapshot of the counters of a Pool.
+	Stats struct {
+		Size    int // Size is the number of Probes in the Pool.
+		Running int // Running is the number of running Probes.
+		Idle    int // Idle is the number of running Probes with no current work.
+		Queued  int // Queued is the number of Runners waiting for a Probe, without cancelled Tasks.
+		Expired int // Expired is the number of Runners dropped because their deadline passed.
+	}
+
 	// Pool is a congigurable collection of Probes that run functions on available goroutines.
 	Pool struct {
+		name       string
+		register   bool
+		ids        probe.IDGenerator
 		logHandler slog.Handler
 		log        *slog.Logger
+		parentCtx  context.Context
 		ctx        context.Context
 		cancel     context.CancelFunc
-		work       chan probe.Runner
-		started    bool
+		stopParent func() bool
+		queue      probe.Queue
+		mu         sync.Mutex
+		state      State
+		drained    chan struct{}
+		paused     atomic.Bool
 		runningCtr *atomic.Int32
 		idleCtr    *atomic.Int32
+		expiredCtr *atomic.Int64
+		skipCtr    atomic.Int64
+		taskSeq    atomic.Uint64
+		onExpired  func(deadline time.Time)
+		watchdog   *watchdog
+		configure  func(i int, cfg *probe.ProbeConfig)
+		tasks      *TaskRegistry
+		queued     queuedDescriptors
+		failures   failureLog
 		waitGroup  *sync.WaitGroup
 		size       int
 		probes     []*probe.Probe
 	}
 )
 
-// NewPool initializes and returns a new Pool.
+// NewPool initializes and returns a new Pool. Settings that do not apply to the configured Scheduling are
+// ignored and logged as a warning.
 func NewPool(cfg *PoolConfig) *Pool {
+	p := newPool(cfg)
+	if cfg.Scheduling != SchedulingFIFO && cfg.Queue != QueueChan {
+		p.log.Warn("queue type only applies to FIFO scheduling and is ignored", "scheduling", cfg.Scheduling)
+	}
+	switch cfg.Scheduling {
+	case SchedulingEDF:
+		p.queue = newDeadlineQueue(cfg.getBufferSize(), p.expire)
+	case SchedulingWorkStealing:
+		p.queue = newStealQueue(cfg.getSize(), cfg.getBufferSize())
+	default:
+		if cfg.Queue == QueueRing {
+			p.queue = newRingQueue(cfg.getBufferSize(), cfg.getSize())
+		} else {
+			p.queue = make(probe.WorkChanQueue, cfg.getBufferSize())
+		}
+	}
+	if cfg.Dedup != DedupOff {
+		// deduplication is layered on top of the queue of the scheduling mode
+		p.queue = newDedupQueue(p.queue, cfg.Dedup)
+	}
+	p.Start()
+	return p
+}
+
+// newPool initializes and returns a new Pool that is not started and has no queue.
+func newPool(cfg *PoolConfig) *Pool {
 	logHandler := cfg.getLogHandler()
 	log := slog.New(cfg.getLogHandler()).With("source", "probe.Pool")
-	childCtx, cancel := context.WithCancel(cfg.getCtx())
-	work := make(chan probe.Runner, cfg.getBufferSize())
+	if cfg.Name != "" {
+		log = log.With("name", cfg.Name)
+	}
 	p := &Pool{
+		name:       cfg.Name,
+		register:   cfg.Register,
+		ids:        cfg.getIDGenerator(),
 		logHandler: logHandler,
 		log:        log,
-		ctx:        childCtx,
-		cancel:     cancel,
-		work:       work,
+		parentCtx:  cfg.getCtx(),
 		runningCtr: new(atomic.Int32),
 		idleCtr:    new(atomic.Int32),
+		expiredCtr: new(atomic.Int64),
+		onExpired:  cfg.OnExpired,
+		tasks:      cfg.Tasks,
 		waitGroup:  new(sync.WaitGroup),
 		size:       cfg.getSize(),
 		probes:     make([]*probe.Probe, 0, cfg.getSize()),
 	}
-	p.Start()
+	if cfg.Watchdog != nil {
+		p.watchdog = newWatchdog(cfg.Watchdog, log)
+	}
 	return p
 }
 
-// Start starts the Pool.
-func (p *Pool) Start() {
-	if p.started {
+// Start starts the Pool. Every start runs the Probes on a fresh child context of the configured context,
+// so a stopped Pool can be started again. If the Pool is draining, Start waits until draining is complete.
+// Start returns ErrRunning if the Pool is already running. The Pool stops on its own when the configured
+// context is done.
+func (p *Pool) Start() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for p.state == StateDraining {
+		drained := p.drained
+		p.mu.Unlock()
+		<-drained
+		p.mu.Lock()
+	}
+	if p.state == StateRunning {
 		p.log.Info("received start request, but pool is already started")
-		return
+		return ErrRunning
 	}
 	p.log.Info("starting pool")
-	for _, p := range p.probes {
-		// run all existing probes on restarts
-		p.Run()
+	p.ctx, p.cancel = context.WithCancel(p.parentCtx)
+	// the Probes exit when the configured context is done, so the Pool stops with them
+	p.stopParent = context.AfterFunc(p.parentCtx, func() {
+		p.Stop(false)
+	})
+	for _, probe := range p.probes {
+		// run all existing probes on restarts, they are all stopped once the pool has drained
+		if err := probe.RunContext(p.ctx); err != nil {
+			p.log.Error("failed to run probe", "probe", probe.ID(), "error", err)
+		}
 	}
 	if len(p.probes) == 0 {
 		// create all probes for new pools
-		for range p.size {
-			p.probes = append(p.probes, probe.NewProbe(&probe.ProbeConfig{
-				LogHandler: p.logHandler,
-				Ctx:        p.ctx,
-				WorkChan:   p.work,
-				RunningCtr: p.runningCtr,
-				IdleCtr:    p.idleCtr,
-				WaitGroup:  p.waitGroup,
-			}))
+		for i := range p.size {
+			cfg := &probe.ProbeConfig{
+				LogHandler:  p.logHandler,
+				IDGenerator: p.ids,
+				Ctx:         p.ctx,
+				Queue:       p.probeQueue(i),
+				RunningCtr:  p.runningCtr,
+				IdleCtr:     p.idleCtr,
+				WaitGroup:   p.waitGroup,
+			}
+			if p.configure != nil {
+				p.configure(i, cfg)
+			}
+			p.probes = append(p.probes, probe.NewProbe(cfg))
 		}
 	}
-	p.started = true
+	if p.watchdog != nil {
+		go p.watchdog.run(p.ctx, p.probes)
+	}
+	if p.register && !pools.Register(p.name, p) {
+		p.log.Warn("failed to register pool, name is empty or already registered")
+	}
+	p.state = StateRunning
+	return nil
+}
+
+// Name returns the configured name of the Pool.
+func (p *Pool) Name() string {
+	return p.name
+}
+
+// probeQueue returns the probe.Queue for the Probe with the given index.
+func (p *Pool) probeQueue(i int) probe.Queue {
+	if q, ok := unwrapQueue(p.queue).(*stealQueue); ok {
+		return q.local(i)
+	}
+	return p.queue
 }
 
-// Stop stops the Pool.
-func (p *Pool) Stop(wait bool) {
-	if !p.started {
+// Stop stops the Pool. The Pool is draining until all Probes have finished their current work. Stop blocks
+// if wait is true until draining is complete. Stop returns ErrNotRunning if the Pool is not running or draining.
+func (p *Pool) Stop(wait bool) error {
+	p.mu.Lock()
+	switch p.state {
+	case StateRunning:
+		p.log.Info("stopping pool")
+		p.state = StateDraining
+		p.stopParent()
+		p.cancel()
+		p.drained = make(chan struct{})
+		go p.drain(p.drained)
+	case StateDraining:
+		p.log.Info("received stop request, but pool is already draining")
+	default:
 		p.log.Info("received stop request, but pool is not started")
-		return
+		p.mu.Unlock()
+		return ErrNotRunning
 	}
-	p.log.Info("stopping pool")
-	p.cancel()
+	drained := p.drained
+	p.mu.Unlock()
 	if wait {
-		p.waitGroup.Wait()
+		<-drained
+	}
+	return nil
+}
+
+// awaitDrained blocks until the Pool has finished draining, if it is draining.
+func (p *Pool) awaitDrained() {
+	p.mu.Lock()
+	drained := p.drained
+	draining := p.state == StateDraining
+	p.mu.Unlock()
+	if draining {
+		<-drained
+	}
+}
+
+// drain waits for all Probes to exit, then marks the Pool as stopped and closes drained.
+func (p *Pool) drain(drained chan struct{}) {
+	p.waitGroup.Wait()
+	p.mu.Lock()
+	p.state = StateStopped
+	if p.register {
+		pools.Unregister(p.name, p)
+	}
+	p.mu.Unlock()
+	p.log.Info("pool stopped")
+	close(drained)
+}
+
+// State returns the lifecycle state of the Pool.
+func (p *Pool) State() State {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.state
+}
+
+// Pause stops all Probes in the Pool from pulling further work, leaving submitted work queued. Work already
+// in progress runs to completion. Run keeps accepting work until the queue is full.
+func (p *Pool) Pause() {
+	p.log.Info("pausing pool")
+	p.paused.Store(true)
+	for _, probe := range p.probes {
+		probe.Pause()
 	}
-	p.started = false
 }
 
-// Run executes a probe.Runner on a Probe in the Pool.
+// Resume resumes pulling work on all Probes in the Pool after Pause.
+func (p *Pool) Resume() {
+	p.log.Info("resuming pool")
+	p.paused.Store(false)
+	for _, probe := range p.probes {
+		probe.Resume()
+	}
+}
+
+// Paused returns true if the Pool is paused.
+func (p *Pool) Paused() bool {
+	return p.paused.Load()
+}
+
+// Run executes a probe.Runner on a Probe in the Pool. A Runner that panics is not recovered and crashes the
+// process. Use Submit to have panics recovered and reported by Failures.
 func (p *Pool) Run(r probe.Runner) {
-	p.work <- r
+	p.queue.Push(probe.Work{Runner: r})
+}
+
+// RunNamed is like Run, but attaches a name and labels to the Runner that are reported by InFlight while
+// it executes.
+func (p *Pool) RunNamed(name string, labels map[string]string, r probe.Runner) {
+	p.queue.Push(probe.Work{Runner: r, Name: name, Labels: labels})
+}
+
+// Submit executes a Task on a Probe in the Pool and returns a TaskHandle to track or cancel it. A Task that
+// panics fails with a *PanicError.
+func (p *Pool) Submit(t Task) *TaskHandle {
+	return p.SubmitNamed("", nil, t)
+}
+
+// SubmitNamed is like Submit, but attaches a name and labels to the Task that are reported by InFlight
+// while it executes.
+func (p *Pool) SubmitNamed(name string, labels map[string]string, t Task) *TaskHandle {
+	h := p.newTaskHandle()
+	pushTask(p.queue, h, p.taskWork(h, name, labels, t))
+	return h
+}
+
+// SubmitDescriptor resolves d to the Handler registered under its name in the PoolConfig.Tasks registry and
+// submits it like SubmitNamed, with the name of d as the name of the Task. SubmitDescriptor returns
+// ErrUnknownTask if no Handler is registered under the name.
+func (p *Pool) SubmitDescriptor(d Descriptor) (*TaskHandle, error) {
+	t, err := p.tasks.Task(d)
+	if err != nil {
+		return nil, err
+	}
+	h := p.newTaskHandle()
+	w := p.taskWork(h, d.Name, nil, t)
+	run := w.Runner
+	w.Runner = func() {
+		// the descriptor is no longer queued, so it is not part of a snapshot
+		p.queued.remove(h)
+		run()
+	}
+	p.queued.add(h, d)
+	pushTask(p.queue, h, w)
+	return h, nil
+}
+
+// SubmitKeyed is like Submit, but deduplicates the Task by an idempotency key while it is queued, according
+// to PoolConfig.Dedup. If a Task with the same key is queued, SubmitKeyed returns its TaskHandle, which then
+// tracks the result for both submitters. The key is reported as the "key" label by InFlight. Tasks with an
+// empty key, and all Tasks of Pools without deduplication, are queued like Submit.
+func (p *Pool) SubmitKeyed(key string, t Task) *TaskHandle {
+	labels := map[string]string{"key": key}
+	q, ok := p.queue.(*dedupQueue)
+	if !ok || key == "" {
+		return p.SubmitNamed("", labels, t)
+	}
+	h := p.newTaskHandle()
+	return q.PushKeyed(key, h, func(h *TaskHandle) probe.Work {
+		return p.taskWork(h, "", labels, t)
+	})
+}
+
+// newTaskHandle returns a new TaskHandle for a Task queued on the Pool. Tasks cancelled while queued that the
+// queue cannot remove stay in it until a Probe pops and skips them, so they are counted until then to be
+// left out of Stats.
+func (p *Pool) newTaskHandle() *TaskHandle {
+	h := newTaskHandle(p.taskSeq.Add(1))
+	h.onCancel = func(removed bool) {
+		if !removed {
+			p.skipCtr.Add(1)
+		}
+	}
+	return h
+}
+
+// taskWork returns the Work that runs the Task tracked by h.
+func (p *Pool) taskWork(h *TaskHandle, name string, labels map[string]string, t Task) probe.Work {
+	return probe.Work{
+		Runner: func() {
+			p.runTask(h, name, labels, t)
+		},
+		Name:   name,
+		Labels: labels,
+		Cancel: func() {
+			h.Cancel()
+		},
+	}
+}
+
+// runTask runs the Task tracked by h and records it if it fails.
+func (p *Pool) runTask(h *TaskHandle, name string, labels map[string]string, t Task) {
+	ctx, ok := h.start(p.context())
+	if !ok {
+		// the task was cancelled while queued
+		p.skipCtr.Add(-1)
+		return
+	}
+	err := CallTask(ctx, t)
+	if h.finish(err) == TaskFailed {
+		p.failures.add(Failure{
+			TaskID: h.ID(),
+			Name:   name,
+			Labels: labels,
+			Err:    err,
+			Time:   time.Now(),
+		})
+	}
+}
+
+// CallTask calls t, returning a *PanicError if t panics. It is how Pools call Tasks, and can be used to
+// give functions run outside of a Task the same panic handling.
+func CallTask(ctx context.Context, t Task) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = &PanicError{Value: v, Stack: debug.Stack()}
+		}
+	}()
+	return t(ctx)
+}
+
+// Failures returns the most recent Tasks submitted with Submit or SubmitNamed that failed with an error
+// or panicked, oldest first.
+func (p *Pool) Failures() []Failure {
+	return p.failures.list()
+}
+
+// context returns the context the Probes of the Pool are currently running on.
+func (p *Pool) context() context.Context {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.ctx
+}
+
+// RunWithDeadline executes a probe.Runner on a Probe in the Pool if it is picked up before deadline.
+// Runners picked up after their deadline are dropped and reported to the PoolConfig.OnExpired callback.
+// Pools using SchedulingEDF run the Runner with the earliest deadline first.
+func (p *Pool) RunWithDeadline(r probe.Runner, deadline time.Time) {
+	if q, ok := unwrapQueue(p.queue).(*deadlineQueue); ok {
+		// the deadline queue drops expired runners itself
+		q.PushDeadline(probe.Work{Runner: r}, deadline)
+		return
+	}
+	p.Run(func() {
+		if time.Now().After(deadline) {
+			p.expire(deadline)
+			return
+		}
+		r()
+	})
+}
+
+// expire records a Runner that was dropped because its deadline passed.
+func (p *Pool) expire(deadline time.Time) {
+	p.expiredCtr.Add(1)
+	p.log.Debug("dropping expired task", "deadline", deadline)
+	if p.onExpired != nil {
+		p.onExpired(deadline)
+	}
+}
+
+// InFlight returns the work currently executing on each busy Probe in the Pool.
+func (p *Pool) InFlight() []probe.InFlight {
+	p.mu.Lock()
+	probes := p.probes
+	p.mu.Unlock()
+	inFlight := make([]probe.InFlight, 0, len(probes))
+	for _, probe := range probes {
+		if current, ok := probe.InFlight(); ok {
+			inFlight = append(inFlight, current)
+		}
+	}
+	return inFlight
 }
 
 // Idle returns the number of idle Probes in the Pool.
@@ -103,3 +446,24 @@ func (p *Pool) Idle() int {
 func (p *Pool) Running() int {
 	return int(p.runningCtr.Load())
 }
+
+// Expired returns the number of Runners dropped by the Pool because their deadline passed.
+func (p *Pool) Expired() int {
+	return int(p.expiredCtr.Load())
+}
+
+// queueLen returns the number of Runners waiting in the queue, without cancelled Tasks waiting to be skipped.
+func (p *Pool) queueLen() int {
+	return max(0, p.queue.Len()-int(p.skipCtr.Load()))
+}
+
+// Stats returns a snapshot of the Pool counters.
+func (p *Pool) Stats() Stats {
+	return Stats{
+		Size:    p.size,
+		Running: p.Running(),
+		Idle:    p.Idle(),
+		Queued:  p.queueLen(),
+		Expired: p.Expired(),
+	}
+}
@@ -1,6 +1,9 @@
 package pool
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
@@ -45,6 +48,67 @@ func waitForIdle(p *probe.Probe) {
 	}
 }
 
+func TestNewPool_IgnoredSettings(t *testing.T) {
+	cases := []struct {
+		cfg   *PoolConfig
+		queue string
+		warn  string
+		msg   string
+	}{
+		{
+			cfg:   &PoolConfig{Scheduling: SchedulingEDF, Queue: QueueRing},
+			queue: "*pool.deadlineQueue",
+			warn:  "queue type only applies to FIFO scheduling",
+			msg:   "NewPool(EDF, QueueRing) -> ring ignored with a warning",
+		},
+		{
+			cfg:   &PoolConfig{Scheduling: SchedulingWorkStealing, Queue: QueueRing},
+			queue: "*pool.stealQueue",
+			warn:  "queue type only applies to FIFO scheduling",
+			msg:   "NewPool(WorkStealing, QueueRing) -> ring ignored with a warning",
+		},
+		{
+			cfg:   &PoolConfig{Queue: QueueRing},
+			queue: "*pool.ringQueue",
+			msg:   "NewPool(QueueRing) -> ring without warning",
+		},
+	}
+	for _, c := range cases {
+		buf := new(bytes.Buffer)
+		c.cfg.LogHandler = slog.NewTextHandler(buf, nil)
+		c.cfg.Size = 1
+		p := NewPool(c.cfg)
+		assert.Equal(t, c.queue, fmt.Sprintf("%T", p.queue), c.msg)
+		if c.warn != "" {
+			assert.Contains(t, buf.String(), c.warn, c.msg)
+		} else {
+			assert.NotContains(t, buf.String(), "level=WARN", c.msg)
+		}
+		p.Stop(true)
+	}
+}
+
+func TestPool_ParentCtxDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	p := NewPool(&PoolConfig{
+		LogHandler: logHandler,
+		Ctx:        ctx,
+		Size:       2,
+	})
+	assert.Equal(t, StateRunning, p.State(), "NewPool -> StateRunning")
+	cancel()
+	for p.State() != StateStopped {
+		time.Sleep(time.Millisecond)
+	}
+	assert.Equal(t, 0, p.Running(), "parent ctx done -> no running probes")
+	assert.ErrorIs(t, p.Stop(true), ErrNotRunning, "Stop(parent ctx done) -> ErrNotRunning")
+	// the Pool stops again right away when started on a done context
+	assert.NoError(t, p.Start(), "Start(parent ctx done) -> err == nil")
+	for p.State() != StateStopped {
+		time.Sleep(time.Millisecond)
+	}
+}
+
 func TestPool_Start(t *testing.T) {
 	cases := []struct {
 		size int
@@ -134,3 +198,297 @@ func TestPool_Running(t *testing.T) {
 	}
 	assert.Equal(t, 16, p.Running(), "NewPool(16) -> p.Running == 16")
 }
+
+func TestPool_RunWithDeadline(t *testing.T) {
+	cases := []struct {
+		scheduling Scheduling
+		e          []int
+		msg        string
+	}{
+		{
+			scheduling: SchedulingFIFO,
+			e:          []int{1, 2, 3},
+			msg:        "RunWithDeadline(SchedulingFIFO) -> submission order",
+		},
+		{
+			scheduling: SchedulingEDF,
+			e:          []int{3, 1, 2},
+			msg:        "RunWithDeadline(SchedulingEDF) -> earliest deadline first",
+		},
+	}
+	for _, c := range cases {
+		expired := make(chan time.Time, 1)
+		p := NewPool(&PoolConfig{
+			LogHandler: logHandler,
+			Size:       1,
+			Scheduling: c.scheduling,
+			OnExpired: func(deadline time.Time) {
+				expired <- deadline
+			},
+		})
+		ctrl := make(chan struct{})
+		started := make(chan struct{})
+		p.Run(func() {
+			close(started)
+			<-ctrl
+		})
+		<-started
+		now := time.Now()
+		order := []int{}
+		wg := new(sync.WaitGroup)
+		wg.Add(3)
+		for i, d := range []time.Duration{2 * time.Hour, 3 * time.Hour, time.Hour} {
+			p.RunWithDeadline(func() {
+				order = append(order, i+1)
+				wg.Done()
+			}, now.Add(d))
+		}
+		past := now.Add(-time.Second)
+		p.RunWithDeadline(func() {
+			t.Error("expired runner executed")
+		}, past)
+		close(ctrl)
+		wg.Wait()
+		assert.Equal(t, past, <-expired, c.msg)
+		assert.Equal(t, c.e, order, c.msg)
+		assert.Equal(t, 1, p.Expired(), c.msg)
+		p.Stop(true)
+	}
+}
+
+func TestPool_Stats(t *testing.T) {
+	expired := make(chan struct{})
+	p := NewPool(&PoolConfig{
+		LogHandler: logHandler,
+		Size:       2,
+		OnExpired: func(_ time.Time) {
+			close(expired)
+		},
+	})
+	for _, probe := range p.probes {
+		waitForIdle(probe)
+	}
+	ctrl := make(chan struct{})
+	started := make(chan struct{}, 2)
+	for range 3 {
+		p.Run(func() {
+			started <- struct{}{}
+			<-ctrl
+		})
+	}
+	<-started
+	<-started
+	p.RunWithDeadline(func() {}, time.Now().Add(-time.Second))
+	assert.Equal(t, Stats{
+		Size:    2,
+		Running: 2,
+		Idle:    0,
+		Queued:  2,
+	}, p.Stats(), "Run x3 + RunWithDeadline -> p.Stats")
+	close(ctrl)
+	<-expired
+	assert.Equal(t, 1, p.Stats().Expired, "OnExpired -> p.Stats.Expired == 1")
+	p.Stop(true)
+}
+
+func TestPool_InFlight(t *testing.T) {
+	p := NewPool(&PoolConfig{
+		LogHandler: logHandler,
+		Size:       3,
+	})
+	for _, probe := range p.probes {
+		waitForIdle(probe)
+	}
+	assert.Empty(t, p.InFlight(), "idle -> p.InFlight == []")
+	ctrl := make(chan struct{})
+	started := make(chan struct{}, 2)
+	p.RunNamed("runner", map[string]string{"kind": "run"}, func() {
+		started <- struct{}{}
+		<-ctrl
+	})
+	h := p.SubmitNamed("task", map[string]string{"kind": "submit"}, func(_ context.Context) error {
+		started <- struct{}{}
+		<-ctrl
+		return nil
+	})
+	<-started
+	<-started
+	inFlight := p.InFlight()
+	assert.Len(t, inFlight, 2, "RunNamed + SubmitNamed -> len(p.InFlight) == 2")
+	names := map[string]string{}
+	for _, f := range inFlight {
+		names[f.Name] = f.Labels["kind"]
+		assert.NotEmpty(t, f.ProbeID, "RunNamed + SubmitNamed -> f.ProbeID != \"\"")
+	}
+	assert.Equal(t, map[string]string{"runner": "run", "task": "submit"}, names, "RunNamed + SubmitNamed -> names and labels")
+	close(ctrl)
+	h.Wait()
+	p.Stop(true)
+	assert.Empty(t, p.InFlight(), "Stop -> p.InFlight == []")
+}
+
+func TestPool_NamedProbeIDs(t *testing.T) {
+	ctrl := make(chan struct{})
+	started := make(chan struct{}, 4)
+	var pools []*Pool
+	for range 2 {
+		p := NewPool(&PoolConfig{
+			LogHandler: logHandler,
+			Name:       "same-name",
+			Size:       2,
+		})
+		defer p.Stop(true)
+		for range 2 {
+			p.Submit(func(_ context.Context) error {
+				started <- struct{}{}
+				<-ctrl
+				return nil
+			})
+		}
+		pools = append(pools, p)
+	}
+	for range 4 {
+		<-started
+	}
+	ids := map[string]struct{}{}
+	for _, p := range pools {
+		for _, f := range p.InFlight() {
+			ids[f.ProbeID] = struct{}{}
+		}
+	}
+	close(ctrl)
+	assert.Len(t, ids, 4, "NewPool(same Name) x2 -> unique probe IDs")
+}
+
+func TestPool_Pause(t *testing.T) {
+	ctr := new(atomic.Int32)
+	p := NewPool(&PoolConfig{
+		LogHandler: logHandler,
+		Size:       4,
+	})
+	for _, probe := range p.probes {
+		waitForRunning(probe)
+	}
+	p.Pause()
+	assert.True(t, p.Paused(), "Pause -> p.Paused == true")
+	for range 8 {
+		p.Run(func() {
+			ctr.Add(1)
+		})
+	}
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, 0, int(ctr.Load()), "Pause + Run x8 -> ctr == 0")
+	assert.Equal(t, 8, p.Stats().Queued, "Pause + Run x8 -> p.Stats.Queued == 8")
+	assert.Equal(t, 4, p.Running(), "Pause -> p.Running == 4")
+	wg := new(sync.WaitGroup)
+	wg.Add(1)
+	p.Run(wg.Done)
+	p.Resume()
+	assert.False(t, p.Paused(), "Resume -> p.Paused == false")
+	wg.Wait()
+	for p.Stats().Queued > 0 {
+		time.Sleep(time.Millisecond)
+	}
+	p.Stop(true)
+	assert.Equal(t, 8, int(ctr.Load()), "Resume -> ctr == 8")
+}
+
+func TestPool_State(t *testing.T) {
+	ctrl := make(chan struct{})
+	started := make(chan struct{})
+	p := NewPool(&PoolConfig{
+		LogHandler: logHandler,
+		Size:       1,
+	})
+	assert.Equal(t, StateRunning, p.State(), "NewPool -> p.State == StateRunning")
+	p.Run(func() {
+		close(started)
+		<-ctrl
+	})
+	<-started
+	p.Stop(false)
+	assert.Equal(t, StateDraining, p.State(), "Stop(false) + busy probe -> p.State == StateDraining")
+	p.Stop(false)
+	close(ctrl)
+	p.Stop(true)
+	assert.Equal(t, StateStopped, p.State(), "Stop(true) -> p.State == StateStopped")
+	p.Stop(true)
+	assert.Equal(t, StateStopped, p.State(), "Stop(true) x2 -> p.State == StateStopped")
+}
+
+func TestPool_Restart(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	p := NewPool(&PoolConfig{
+		LogHandler: logHandler,
+		Ctx:        ctx,
+		Size:       4,
+	})
+	for range 3 {
+		p.Stop(true)
+		p.Start()
+		assert.Equal(t, StateRunning, p.State(), "Stop + Start -> p.State == StateRunning")
+		for _, probe := range p.probes {
+			waitForRunning(probe)
+		}
+		assert.Equal(t, 4, p.Running(), "Stop + Start -> p.Running == 4")
+		assert.Equal(t, 4, p.Idle(), "Stop + Start -> p.Idle == 4")
+		done := make(chan struct{})
+		p.Run(func() {
+			close(done)
+		})
+		<-done
+	}
+	p.Stop(false)
+	// Start waits for the pool to finish draining before starting again
+	p.Start()
+	assert.Equal(t, StateRunning, p.State(), "Stop(false) + Start -> p.State == StateRunning")
+	p.Stop(true)
+}
+
+func TestPool_StartStopConcurrent(t *testing.T) {
+	ctr := new(atomic.Int32)
+	p := NewPool(&PoolConfig{
+		LogHandler: logHandler,
+		Size:       4,
+	})
+	wg := new(sync.WaitGroup)
+	for i := range 16 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range 16 {
+				var err error
+				switch i % 4 {
+				case 0:
+					err = p.Start()
+				case 1, 2:
+					err = p.Stop(i%4 == 1)
+				case 3:
+					p.Run(func() {
+						ctr.Add(1)
+					})
+					p.State()
+					p.Stats()
+				}
+				if err != nil && !errors.Is(err, ErrRunning) && !errors.Is(err, ErrNotRunning) {
+					t.Errorf("unexpected error: %v", err)
+				}
+				assert.LessOrEqual(t, p.Running(), 4, fmt.Sprintf("concurrent Start/Stop [%d] -> p.Running <= 4", j))
+			}
+		}()
+	}
+	wg.Wait()
+	p.Start()
+	for p.Stats().Queued > 0 {
+		time.Sleep(time.Millisecond)
+	}
+	assert.NoError(t, p.Stop(true), "Stop(true) -> nil")
+	assert.Equal(t, StateStopped, p.State(), "Stop(true) -> p.State == StateStopped")
+	assert.Equal(t, 0, p.Running(), "Stop(true) -> p.Running == 0")
+	assert.Equal(t, 64, int(ctr.Load()), "concurrent Run x64 -> ctr == 64")
+	assert.ErrorIs(t, p.Stop(true), ErrNotRunning, "Stop(stopped) -> ErrNotRunning")
+	assert.NoError(t, p.Start(), "Start(stopped) -> nil")
+	assert.ErrorIs(t, p.Start(), ErrRunning, "Start(running) -> ErrRunning")
+	p.Stop(true)
+}
@@ -0,0 +1,19 @@
+package pool
+
+import (
+	"github.com/amplify-security/probe"
+)
+
+var (
+	pools = new(probe.Registry[*Pool]) // pools is the process-wide registry of Pools.
+)
+
+// Lookup returns the registered running Pool with the given name.
+func Lookup(name string) (*Pool, bool) {
+	return pools.Lookup(name)
+}
+
+// Registered returns all registered running Pools, ordered by name.
+func Registered() []*Pool {
+	return pools.All()
+}
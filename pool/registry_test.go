@@ -0,0 +1,49 @@
+package pool
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPool_Register(t *testing.T) {
+	p := NewPool(&PoolConfig{
+		LogHandler: logHandler,
+		Name:       "registered",
+		Register:   true,
+		Size:       1,
+	})
+	assert.Equal(t, "registered", p.Name(), "NewPool -> p.Name == registered")
+	found, ok := Lookup("registered")
+	assert.True(t, ok, "Register -> Lookup ok == true")
+	assert.Same(t, p, found, "Register -> Lookup == p")
+	assert.Contains(t, Registered(), p, "Register -> Registered contains p")
+	assert.True(t, strings.HasPrefix(p.probes[0].ID(), "registered-"), "Name -> probe ID == registered-N")
+	p.Stop(true)
+	_, ok = Lookup("registered")
+	assert.False(t, ok, "Stop -> Lookup ok == false")
+	p.Start()
+	_, ok = Lookup("registered")
+	assert.True(t, ok, "Start -> Lookup ok == true")
+	p.Stop(true)
+}
+
+func TestShardedPool_Register(t *testing.T) {
+	p := NewShardedPool(&ShardedPoolConfig{
+		Shards: 2,
+		Shard: &PoolConfig{
+			LogHandler: logHandler,
+			Name:       "sharded",
+			Register:   true,
+			Size:       1,
+		},
+	})
+	for i, name := range []string{"sharded-0", "sharded-1"} {
+		found, ok := Lookup(name)
+		assert.True(t, ok, "NewShardedPool -> Lookup(shard) ok == true")
+		assert.Same(t, p.Shards()[i], found, "NewShardedPool -> Lookup(shard) == shard")
+	}
+	p.Stop(true)
+	assert.NotContains(t, Registered(), p.Shards()[0], "Stop -> Registered does not contain shard")
+}
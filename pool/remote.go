@@ -0,0 +1,316 @@
+package pool
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type (
+	// RemoteError is the error of a task that failed on a Server.
+	RemoteError struct {
+		Message string // Message is the error message reported by the Server.
+	}
+
+	// RemotePool submits Descriptors to a Pool in another process through its Server. SubmitDescriptor
+	// returns once the Server accepts the task, and the returned TaskHandle tracks the task on the Server:
+	// it finishes with the result reported by the Server, and cancelling it cancels the task on the Server.
+	// The RemotePool connects on first use, and reconnects on the next submission after the connection is
+	// lost.
+	RemotePool struct {
+		network     string
+		address     string
+		log         *slog.Logger
+		dialTimeout time.Duration
+		ackTimeout  time.Duration
+		taskTimeout time.Duration
+		seq         atomic.Uint64
+		mu          sync.Mutex
+		conn        *remoteConn
+		closed      bool
+	}
+
+	// remoteConn is a connection of a RemotePool to a Server.
+	remoteConn struct {
+		conn    net.Conn
+		w       *remoteWriter
+		mu      sync.Mutex
+		calls   map[uint64]*remoteCall
+		done    chan struct{}
+		err     error
+		timeout time.Duration
+		ack     time.Duration
+	}
+
+	// remoteCall is a task submitted to a Server.
+	remoteCall struct {
+		h      *TaskHandle
+		acked  chan error
+		finish sync.Once
+	}
+)
+
+var (
+	ErrRemoteClosed  = errors.New("pool: remote pool closed")     // ErrRemoteClosed is returned after Close.
+	ErrAckTimeout    = errors.New("pool: remote ack timeout")     // ErrAckTimeout is returned for unaccepted tasks.
+	ErrRemoteTimeout = errors.New("pool: remote task timeout")    // ErrRemoteTimeout is the error of unreported tasks.
+	ErrConnLost      = errors.New("pool: remote connection lost") // ErrConnLost is the error of tasks in flight.
+)
+
+// Error implementation of error for RemoteError.
+func (e *RemoteError) Error() string {
+	return "pool: remote task failed: " + e.Message
+}
+
+// NewRemotePool initializes and returns a new RemotePool. NewRemotePool does not connect to the Server.
+func NewRemotePool(cfg *RemotePoolConfig) *RemotePool {
+	return &RemotePool{
+		network:     cfg.Network,
+		address:     cfg.Address,
+		log:         slog.New(cfg.getLogHandler()).With("source", "probe.RemotePool", "address", cfg.Address),
+		dialTimeout: cfg.getDialTimeout(),
+		ackTimeout:  cfg.getAckTimeout(),
+		taskTimeout: cfg.TaskTimeout,
+	}
+}
+
+// SubmitDescriptor submits d to the Server and returns a TaskHandle to track or cancel it once the Server
+// accepts it. SubmitDescriptor returns ErrUnknownTask if the Server has no Handler registered under the
+// name of d, ErrAckTimeout if the Server does not accept the task in time, and the error of the connection
+// if it cannot connect or the connection is lost before the task is accepted. If the connection is lost
+// after the task is accepted, the TaskHandle fails with ErrConnLost, and the task may or may not finish on
+// the Server. Tasks that fail on the Server fail with a *RemoteError.
+func (p *RemotePool) SubmitDescriptor(d Descriptor) (*TaskHandle, error) {
+	c, err := p.connect()
+	if err != nil {
+		return nil, err
+	}
+	id := p.seq.Add(1)
+	call := &remoteCall{
+		h:     newTaskHandle(id),
+		acked: make(chan error, 1),
+	}
+	if !c.add(id, call) {
+		return nil, c.err
+	}
+	if err := c.w.send(remoteMessage{Type: remoteSubmit, ID: id, Name: d.Name, Payload: d.Payload}); err != nil {
+		c.remove(id)
+		c.fail(err)
+		return nil, err
+	}
+	timer := time.NewTimer(p.ackTimeout)
+	defer timer.Stop()
+	select {
+	case err := <-call.acked:
+		if err != nil {
+			return nil, err
+		}
+	case <-timer.C:
+		if c.remove(id) == nil {
+			// the Server answered or the connection was lost at the same time
+			if err := <-call.acked; err != nil {
+				return nil, err
+			}
+			return call.h, nil
+		}
+		// the task may still be accepted, or just was: cancel it on the Server
+		call.h.Cancel()
+		c.w.send(remoteMessage{Type: remoteCancel, ID: id})
+		return nil, ErrAckTimeout
+	}
+	return call.h, nil
+}
+
+// Close closes the connection to the Server. Tasks in flight fail with ErrConnLost.
+func (p *RemotePool) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	if p.conn == nil {
+		return nil
+	}
+	err := p.conn.conn.Close()
+	p.conn = nil
+	return err
+}
+
+// connect returns the current connection to the Server, connecting if there is none or it was lost.
+func (p *RemotePool) connect() (*remoteConn, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return nil, ErrRemoteClosed
+	}
+	if p.conn != nil {
+		select {
+		case <-p.conn.done:
+			p.log.Info("reconnecting", "error", p.conn.err)
+		default:
+			return p.conn, nil
+		}
+	}
+	conn, err := net.DialTimeout(p.network, p.address, p.dialTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("pool: failed to connect to server: %w", err)
+	}
+	p.conn = &remoteConn{
+		conn:    conn,
+		w:       newRemoteWriter(conn),
+		calls:   map[uint64]*remoteCall{},
+		done:    make(chan struct{}),
+		timeout: p.taskTimeout,
+		ack:     p.ackTimeout,
+	}
+	go p.conn.read()
+	p.log.Debug("connected")
+	return p.conn, nil
+}
+
+// add tracks call on the connection. add returns false if the connection is lost.
+func (c *remoteConn) add(id uint64, call *remoteCall) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return false
+	}
+	c.calls[id] = call
+	return true
+}
+
+// remove stops tracking the call with the given ID and returns it.
+func (c *remoteConn) remove(id uint64) *remoteCall {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	call := c.calls[id]
+	delete(c.calls, id)
+	return call
+}
+
+// fail marks the connection as lost, closes it and fails all calls in flight.
+func (c *remoteConn) fail(err error) {
+	c.mu.Lock()
+	if c.err != nil {
+		c.mu.Unlock()
+		return
+	}
+	c.err = fmt.Errorf("%w: %w", ErrConnLost, err)
+	calls := c.calls
+	c.calls = nil
+	close(c.done)
+	c.mu.Unlock()
+	c.conn.Close()
+	for _, call := range calls {
+		select {
+		case call.acked <- c.err:
+		default:
+			// the call was already accepted
+		}
+		call.complete("", c.err)
+	}
+}
+
+// read reads messages from the Server until the connection is lost.
+func (c *remoteConn) read() {
+	dec := json.NewDecoder(bufio.NewReader(c.conn))
+	for {
+		var msg remoteMessage
+		if err := dec.Decode(&msg); err != nil {
+			c.fail(err)
+			return
+		}
+		switch msg.Type {
+		case remoteAck:
+			c.mu.Lock()
+			call := c.calls[msg.ID]
+			c.mu.Unlock()
+			if call == nil {
+				continue
+			}
+			c.start(msg.ID, call)
+			select {
+			case call.acked <- nil:
+			default:
+				// the connection was lost while accepting the task
+			}
+		case remoteReject:
+			call := c.remove(msg.ID)
+			if call == nil {
+				continue
+			}
+			err := error(&RemoteError{Message: msg.Error})
+			if msg.Code == remoteCodeUnknownTask {
+				err = fmt.Errorf("%w: %s", ErrUnknownTask, msg.Error)
+			}
+			call.acked <- err
+		case remoteResult:
+			if call := c.remove(msg.ID); call != nil {
+				var err error
+				if msg.Error != "" {
+					err = &RemoteError{Message: msg.Error}
+				}
+				call.complete(msg.Status, err)
+			}
+		}
+	}
+}
+
+// start moves the accepted task to TaskRunning and cancels it on the Server when its TaskHandle is
+// cancelled or the task times out.
+func (c *remoteConn) start(id uint64, call *remoteCall) {
+	parent, cancel := context.Background(), context.CancelFunc(func() {})
+	if c.timeout > 0 {
+		parent, cancel = context.WithTimeout(parent, c.timeout)
+	}
+	ctx, ok := call.h.start(parent)
+	if !ok {
+		// the TaskHandle was cancelled before the task was accepted
+		cancel()
+		c.remove(id)
+		c.w.send(remoteMessage{Type: remoteCancel, ID: id})
+		return
+	}
+	go func() {
+		defer cancel()
+		select {
+		case <-call.h.Done():
+			return
+		case <-ctx.Done():
+		}
+		c.w.send(remoteMessage{Type: remoteCancel, ID: id})
+		if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return
+		}
+		// give the Server the ack timeout to report the cancelled task before failing it locally
+		timer := time.NewTimer(c.ack)
+		defer timer.Stop()
+		select {
+		case <-call.h.Done():
+		case <-timer.C:
+			if c.remove(id) != nil {
+				call.complete("", ErrRemoteTimeout)
+			}
+		}
+	}()
+}
+
+// complete finishes the running TaskHandle of the call with the status and error reported by the Server.
+func (call *remoteCall) complete(status string, err error) {
+	call.finish.Do(func() {
+		call.h.mu.Lock()
+		running := call.h.status == TaskRunning
+		if status == TaskCancelled.String() {
+			call.h.cancelled = true
+		}
+		call.h.mu.Unlock()
+		if running {
+			call.h.finish(err)
+		}
+	})
+}
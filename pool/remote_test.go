@@ -0,0 +1,207 @@
+package pool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// serve starts a Server for a new Pool running tasks on the given listener.
+func serve(t *testing.T, l net.Listener, tasks *TaskRegistry) *Server {
+	p := NewPool(&PoolConfig{
+		LogHandler: logHandler,
+		Size:       2,
+		Tasks:      tasks,
+	})
+	s := NewServer(p, &ServerConfig{LogHandler: logHandler})
+	served := make(chan error, 1)
+	go func() {
+		served <- s.Serve(l)
+	}()
+	t.Cleanup(func() {
+		s.Close()
+		assert.ErrorIs(t, <-served, ErrServerClosed, "Server.Serve -> ErrServerClosed after Close")
+		p.Stop(true)
+	})
+	return s
+}
+
+func remoteTasks() *TaskRegistry {
+	tasks := NewTaskRegistry()
+	tasks.Register("echo", func(_ context.Context, payload []byte) error {
+		if len(payload) > 0 {
+			return errors.New(string(payload))
+		}
+		return nil
+	})
+	tasks.Register("block", func(ctx context.Context, _ []byte) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	return tasks
+}
+
+func TestRemotePool_SubmitDescriptor(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "probe.sock")
+	l, err := net.Listen("unix", path)
+	assert.NoError(t, err, "net.Listen -> err == nil")
+	serve(t, l, remoteTasks())
+	r := NewRemotePool(&RemotePoolConfig{
+		Network:    "unix",
+		Address:    path,
+		LogHandler: logHandler,
+	})
+	defer r.Close()
+	var _ DescriptorSubmitter = r
+	h, err := r.SubmitDescriptor(Descriptor{Name: "echo"})
+	assert.NoError(t, err, "RemotePool.SubmitDescriptor -> err == nil")
+	assert.NoError(t, h.Wait(), "RemotePool.SubmitDescriptor -> task done")
+	assert.Equal(t, TaskDone, h.Status(), "RemotePool.SubmitDescriptor -> TaskDone")
+	h, err = r.SubmitDescriptor(Descriptor{Name: "echo", Payload: []byte("boom")})
+	assert.NoError(t, err, "RemotePool.SubmitDescriptor -> err == nil")
+	var remoteErr *RemoteError
+	assert.ErrorAs(t, h.Wait(), &remoteErr, "RemotePool.SubmitDescriptor -> *RemoteError")
+	assert.Equal(t, "boom", remoteErr.Message, "RemotePool.SubmitDescriptor -> remote error message")
+	assert.Equal(t, TaskFailed, h.Status(), "RemotePool.SubmitDescriptor -> TaskFailed")
+	h, err = r.SubmitDescriptor(Descriptor{Name: "missing"})
+	assert.ErrorIs(t, err, ErrUnknownTask, "RemotePool.SubmitDescriptor -> ErrUnknownTask")
+	assert.Nil(t, h, "RemotePool.SubmitDescriptor -> no handle for rejected task")
+	assert.NoError(t, r.Close(), "RemotePool.Close -> err == nil")
+	_, err = r.SubmitDescriptor(Descriptor{Name: "echo"})
+	assert.ErrorIs(t, err, ErrRemoteClosed, "RemotePool.SubmitDescriptor -> ErrRemoteClosed after Close")
+}
+
+func TestRemotePool_Cancel(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err, "net.Listen -> err == nil")
+	serve(t, l, remoteTasks())
+	r := NewRemotePool(&RemotePoolConfig{
+		Network:    "tcp",
+		Address:    l.Addr().String(),
+		LogHandler: logHandler,
+	})
+	defer r.Close()
+	h, err := r.SubmitDescriptor(Descriptor{Name: "block"})
+	assert.NoError(t, err, "RemotePool.SubmitDescriptor -> err == nil")
+	assert.True(t, h.Cancel(), "TaskHandle.Cancel -> true")
+	var remoteErr *RemoteError
+	assert.ErrorAs(t, h.Wait(), &remoteErr, "TaskHandle.Cancel -> remote task cancelled")
+	assert.Equal(t, context.Canceled.Error(), remoteErr.Message, "TaskHandle.Cancel -> remote context canceled")
+	assert.Equal(t, TaskCancelled, h.Status(), "TaskHandle.Cancel -> TaskCancelled")
+}
+
+func TestRemotePool_TaskTimeout(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err, "net.Listen -> err == nil")
+	serve(t, l, remoteTasks())
+	r := NewRemotePool(&RemotePoolConfig{
+		Network:     "tcp",
+		Address:     l.Addr().String(),
+		LogHandler:  logHandler,
+		TaskTimeout: 20 * time.Millisecond,
+	})
+	defer r.Close()
+	h, err := r.SubmitDescriptor(Descriptor{Name: "block"})
+	assert.NoError(t, err, "RemotePool.SubmitDescriptor -> err == nil")
+	assert.Error(t, h.Wait(), "RemotePoolConfig.TaskTimeout -> remote task cancelled")
+	assert.Equal(t, TaskCancelled, h.Status(), "RemotePoolConfig.TaskTimeout -> TaskCancelled")
+}
+
+func TestRemotePool_AckTimeout(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err, "net.Listen -> err == nil")
+	defer l.Close()
+	go func() {
+		// accept connections but never answer
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+	r := NewRemotePool(&RemotePoolConfig{
+		Network:    "tcp",
+		Address:    l.Addr().String(),
+		LogHandler: logHandler,
+		AckTimeout: 20 * time.Millisecond,
+	})
+	defer r.Close()
+	h, err := r.SubmitDescriptor(Descriptor{Name: "echo"})
+	assert.ErrorIs(t, err, ErrAckTimeout, "RemotePool.SubmitDescriptor -> ErrAckTimeout")
+	assert.Nil(t, h, "RemotePool.SubmitDescriptor -> no handle on ack timeout")
+}
+
+func TestRemotePool_ResultTimeout(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err, "net.Listen -> err == nil")
+	defer l.Close()
+	go func() {
+		// accept tasks but never report a result, not even for cancelled tasks
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		w := newRemoteWriter(conn)
+		dec := json.NewDecoder(conn)
+		for {
+			var msg remoteMessage
+			if err := dec.Decode(&msg); err != nil {
+				return
+			}
+			if msg.Type == remoteSubmit {
+				w.send(remoteMessage{Type: remoteAck, ID: msg.ID})
+			}
+		}
+	}()
+	r := NewRemotePool(&RemotePoolConfig{
+		Network:     "tcp",
+		Address:     l.Addr().String(),
+		LogHandler:  logHandler,
+		AckTimeout:  20 * time.Millisecond,
+		TaskTimeout: 20 * time.Millisecond,
+	})
+	defer r.Close()
+	h, err := r.SubmitDescriptor(Descriptor{Name: "block"})
+	assert.NoError(t, err, "RemotePool.SubmitDescriptor -> err == nil")
+	select {
+	case <-h.Done():
+	case <-time.After(time.Second):
+		t.Fatal("RemotePoolConfig.AckTimeout -> unreported task not failed in time")
+	}
+	assert.ErrorIs(t, h.Err(), ErrRemoteTimeout, "RemotePoolConfig.TaskTimeout -> ErrRemoteTimeout")
+}
+
+func TestRemotePool_Reconnect(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "probe.sock")
+	l, err := net.Listen("unix", path)
+	assert.NoError(t, err, "net.Listen -> err == nil")
+	s := serve(t, l, remoteTasks())
+	r := NewRemotePool(&RemotePoolConfig{
+		Network:    "unix",
+		Address:    path,
+		LogHandler: logHandler,
+	})
+	defer r.Close()
+	h, err := r.SubmitDescriptor(Descriptor{Name: "block"})
+	assert.NoError(t, err, "RemotePool.SubmitDescriptor -> err == nil")
+	s.Close()
+	assert.ErrorIs(t, h.Wait(), ErrConnLost, "Server.Close -> ErrConnLost")
+	assert.Equal(t, TaskFailed, h.Status(), "Server.Close -> TaskFailed")
+	_, err = r.SubmitDescriptor(Descriptor{Name: "echo"})
+	assert.Error(t, err, "RemotePool.SubmitDescriptor -> err != nil without a Server")
+	l, err = net.Listen("unix", path)
+	assert.NoError(t, err, "net.Listen -> err == nil")
+	serve(t, l, remoteTasks())
+	h, err = r.SubmitDescriptor(Descriptor{Name: "echo"})
+	assert.NoError(t, err, "RemotePool.SubmitDescriptor -> reconnected")
+	assert.NoError(t, h.Wait(), "RemotePool.SubmitDescriptor -> task done after reconnect")
+}
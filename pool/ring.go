@@ -0,0 +1,131 @@
+package pool
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/amplify-security/probe"
+)
+
+// cacheLine is the assumed size of a CPU cache line, used to keep hot atomics from false sharing.
+const cacheLine = 64
+
+type (
+	// ringSlot is a single cell of a ringQueue. seq tells producers and consumers whose turn it is to use
+	// the cell.
+	ringSlot struct {
+		seq  atomic.Uint64
+		work probe.Work
+	}
+
+	// ringQueue is a lock-free bounded multi-producer/multi-consumer probe.Queue based on Dmitry Vyukov's
+	// ring buffer design. Producers and consumers only park when the queue is full or empty respectively.
+	ringQueue struct {
+		_     [cacheLine]byte
+		tail  atomic.Uint64
+		_     [cacheLine - 8]byte
+		head  atomic.Uint64
+		_     [cacheLine - 8]byte
+		mask  uint64
+		slots []ringSlot
+		ready *parking
+		space *parking
+	}
+)
+
+// newRingQueue initializes and returns a new ringQueue. The capacity is size rounded up to a power of two,
+// with a minimum of two since a single slot cannot tell a full queue from an empty one. waiters is the
+// expected number of concurrently parked consumers.
+func newRingQueue(size, waiters int) *ringQueue {
+	n := 2
+	for n < size {
+		n <<= 1
+	}
+	q := &ringQueue{
+		mask:  uint64(n - 1),
+		slots: make([]ringSlot, n),
+		ready: newParking(waiters),
+		space: newParking(waiters),
+	}
+	for i := range q.slots {
+		q.slots[i].seq.Store(uint64(i))
+	}
+	return q
+}
+
+// Push implementation of probe.Queue for ringQueue. Push blocks while the queue is full.
+func (q *ringQueue) Push(w probe.Work) {
+	wait(q.space, context.Background(), func() (struct{}, bool) {
+		return struct{}{}, q.tryPush(w)
+	})
+	q.ready.notify()
+}
+
+// tryPush attempts to add Work to the queue without blocking. tryPush returns false if the queue is full.
+func (q *ringQueue) tryPush(w probe.Work) bool {
+	pos := q.tail.Load()
+	for {
+		slot := &q.slots[pos&q.mask]
+		switch diff := int64(slot.seq.Load() - pos); {
+		case diff == 0:
+			// the slot is free for this position, claim it
+			if q.tail.CompareAndSwap(pos, pos+1) {
+				slot.work = w
+				slot.seq.Store(pos + 1)
+				return true
+			}
+			pos = q.tail.Load()
+		case diff < 0:
+			// the slot still holds Work from the previous lap
+			return false
+		default:
+			// another producer claimed this position
+			pos = q.tail.Load()
+		}
+	}
+}
+
+// Pop implementation of probe.Queue for ringQueue. Pop parks the calling Probe while the queue is empty.
+func (q *ringQueue) Pop(ctx context.Context) (probe.Work, bool) {
+	w, ok := wait(q.ready, ctx, q.tryPop)
+	if ok {
+		q.space.notify()
+	}
+	return w, ok
+}
+
+// tryPop attempts to remove Work from the queue without blocking. tryPop returns false if the queue is
+// empty.
+func (q *ringQueue) tryPop() (probe.Work, bool) {
+	pos := q.head.Load()
+	for {
+		slot := &q.slots[pos&q.mask]
+		switch diff := int64(slot.seq.Load() - (pos + 1)); {
+		case diff == 0:
+			// the slot holds Work for this position, claim it
+			if q.head.CompareAndSwap(pos, pos+1) {
+				w := slot.work
+				slot.work = probe.Work{}
+				slot.seq.Store(pos + q.mask + 1)
+				return w, true
+			}
+			pos = q.head.Load()
+		case diff < 0:
+			// the slot has not been filled yet
+			return probe.Work{}, false
+		default:
+			// another consumer claimed this position
+			pos = q.head.Load()
+		}
+	}
+}
+
+// Len implementation of probe.Queue for ringQueue. Len is approximate while the queue is in use.
+func (q *ringQueue) Len() int {
+	head := q.head.Load()
+	tail := q.tail.Load()
+	if tail < head {
+		return 0
+	}
+	return int(tail - head)
+}
@@ -0,0 +1,199 @@
+package pool
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/amplify-security/probe"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRingQueue_PushPop(t *testing.T) {
+	order := []int{}
+	q := newRingQueue(3, 1)
+	assert.Len(t, q.slots, 4, "newRingQueue(3) -> len(q.slots) == 4")
+	for i := range 4 {
+		q.Push(probe.Work{Runner: func() {
+			order = append(order, i)
+		}})
+	}
+	assert.False(t, q.tryPush(probe.Work{Runner: func() {}}), "tryPush(full) -> false")
+	assert.Equal(t, 4, q.Len(), "Push x4 -> q.Len == 4")
+	ctx, cancel := context.WithCancel(context.Background())
+	for range 4 {
+		w, ok := q.Pop(ctx)
+		assert.True(t, ok, "Pop -> ok == true")
+		w.Runner()
+	}
+	assert.Equal(t, []int{0, 1, 2, 3}, order, "Pop x4 -> submission order")
+	assert.Equal(t, 0, q.Len(), "Pop x4 -> q.Len == 0")
+	_, ok := q.tryPop()
+	assert.False(t, ok, "tryPop(empty) -> false")
+	cancel()
+	_, ok = q.Pop(ctx)
+	assert.False(t, ok, "Pop(cancelled ctx) -> ok == false")
+}
+
+func TestRingQueue_Park(t *testing.T) {
+	q := newRingQueue(1, 1)
+	assert.Len(t, q.slots, 2, "newRingQueue(1) -> len(q.slots) == 2")
+	q.Push(probe.Work{Runner: func() {}})
+	q.Push(probe.Work{Runner: func() {}})
+	pushed := make(chan struct{})
+	go func() {
+		// the queue is full, so this parks until Work is popped
+		q.Push(probe.Work{Runner: func() {}})
+		close(pushed)
+	}()
+	select {
+	case <-pushed:
+		t.Error("Push(full) -> did not block")
+	case <-time.After(10 * time.Millisecond):
+	}
+	ctx := context.Background()
+	_, ok := q.Pop(ctx)
+	assert.True(t, ok, "Pop -> ok == true")
+	<-pushed
+	for range 2 {
+		_, ok = q.Pop(ctx)
+		assert.True(t, ok, "Pop -> ok == true")
+	}
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		q.Push(probe.Work{Runner: func() {}})
+	}()
+	// the queue is empty, so this parks until Work is pushed
+	_, ok = q.Pop(ctx)
+	assert.True(t, ok, "Pop(parked) + Push -> ok == true")
+}
+
+func TestRingQueue_Concurrent(t *testing.T) {
+	q := newRingQueue(16, 8)
+	ctr := new(atomic.Int32)
+	ctx, cancel := context.WithCancel(context.Background())
+	consumers := new(sync.WaitGroup)
+	for range 8 {
+		consumers.Add(1)
+		go func() {
+			defer consumers.Done()
+			for {
+				w, ok := q.Pop(ctx)
+				if !ok {
+					return
+				}
+				w.Runner()
+			}
+		}()
+	}
+	producers := new(sync.WaitGroup)
+	done := new(sync.WaitGroup)
+	done.Add(8 * 1000)
+	for range 8 {
+		producers.Add(1)
+		go func() {
+			defer producers.Done()
+			for range 1000 {
+				q.Push(probe.Work{Runner: func() {
+					ctr.Add(1)
+					done.Done()
+				}})
+			}
+		}()
+	}
+	producers.Wait()
+	done.Wait()
+	cancel()
+	consumers.Wait()
+	assert.Equal(t, 8000, int(ctr.Load()), "8 producers x1000 -> ctr == 8000")
+}
+
+func TestPool_RunRing(t *testing.T) {
+	wg := new(sync.WaitGroup)
+	wg.Add(256)
+	p := NewPool(&PoolConfig{
+		LogHandler: logHandler,
+		Size:       4,
+		BufferSize: 8,
+		Queue:      QueueRing,
+	})
+	for range 256 {
+		p.Run(wg.Done)
+	}
+	wg.Wait()
+	_, ok := p.queue.(*ringQueue)
+	assert.True(t, ok, "QueueRing -> p.queue is *ringQueue")
+	p.Stop(true)
+}
+
+/*
+BenchmarkQueue measures raw queue throughput with the given number of producer and consumer goroutines,
+without Probes in the way. Compare ns/op of chan and ring at each level of contention, and across -cpu
+values, to see where the ring buffer beats the channel on a given machine.
+*/
+func BenchmarkQueue(b *testing.B) {
+	queues := []struct {
+		name string
+		new  func() probe.Queue
+	}{
+		{
+			name: "chan",
+			new: func() probe.Queue {
+				return make(probe.WorkChanQueue, 1024)
+			},
+		},
+		{
+			name: "ring",
+			new: func() probe.Queue {
+				return newRingQueue(1024, 64)
+			},
+		},
+	}
+	for _, q := range queues {
+		for _, n := range []int{1, 4, 16, 64} {
+			b.Run(fmt.Sprintf("%s/producers=%d/consumers=%d", q.name, n, n), func(b *testing.B) {
+				benchmarkQueue(b, q.new(), n, n)
+			})
+		}
+	}
+}
+
+func benchmarkQueue(b *testing.B, q probe.Queue, producers, consumers int) {
+	ctx, cancel := context.WithCancel(context.Background())
+	wg := new(sync.WaitGroup)
+	for range consumers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				if _, ok := q.Pop(ctx); !ok {
+					return
+				}
+			}
+		}()
+	}
+	w := probe.Work{Runner: func() {}}
+	remaining := new(atomic.Int64)
+	remaining.Store(int64(b.N))
+	b.ResetTimer()
+	pwg := new(sync.WaitGroup)
+	for range producers {
+		pwg.Add(1)
+		go func() {
+			defer pwg.Done()
+			for remaining.Add(-1) >= 0 {
+				q.Push(w)
+			}
+		}()
+	}
+	pwg.Wait()
+	for q.Len() > 0 {
+		time.Sleep(time.Microsecond)
+	}
+	b.StopTimer()
+	cancel()
+	wg.Wait()
+}
@@ -0,0 +1,204 @@
+package pool
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net"
+	"sync"
+)
+
+const (
+	remoteSubmit = "submit" // remoteSubmit submits a Descriptor to the Server.
+	remoteCancel = "cancel" // remoteCancel cancels a submitted task.
+	remoteAck    = "ack"    // remoteAck accepts a submitted task.
+	remoteReject = "reject" // remoteReject rejects a submitted task.
+	remoteResult = "result" // remoteResult reports the final status of an accepted task.
+)
+
+const (
+	remoteCodeUnknownTask = "unknown_task" // remoteCodeUnknownTask rejects a task without a registered Handler.
+)
+
+type (
+	// remoteMessage is a message between a RemotePool and a Server. Messages are sent as JSON lines.
+	remoteMessage struct {
+		Type    string `json:"type"`
+		ID      uint64 `json:"id"`
+		Name    string `json:"name,omitempty"`
+		Payload []byte `json:"payload,omitempty"`
+		Status  string `json:"status,omitempty"`
+		Code    string `json:"code,omitempty"`
+		Error   string `json:"error,omitempty"`
+	}
+
+	// remoteWriter sends remoteMessages on a connection. It is safe for concurrent use.
+	remoteWriter struct {
+		mu  sync.Mutex
+		enc *json.Encoder
+	}
+
+	// Server exposes a Pool to RemotePools over a stream connection, usually a Unix domain socket or a
+	// loopback TCP socket. Submitted Descriptors are resolved by the PoolConfig.Tasks registry of the Pool.
+	// Tasks keep running when the connection of their RemotePool is lost.
+	Server struct {
+		pool      *Pool
+		log       *slog.Logger
+		mu        sync.Mutex
+		closed    bool
+		listeners map[net.Listener]struct{}
+		conns     map[net.Conn]struct{}
+		waitGroup sync.WaitGroup
+	}
+)
+
+var (
+	ErrServerClosed = errors.New("pool: server closed") // ErrServerClosed is returned by Serve after Close.
+)
+
+// newRemoteWriter initializes and returns a new remoteWriter for conn.
+func newRemoteWriter(conn net.Conn) *remoteWriter {
+	return &remoteWriter{enc: json.NewEncoder(conn)}
+}
+
+// send writes msg to the connection.
+func (w *remoteWriter) send(msg remoteMessage) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.enc.Encode(msg)
+}
+
+// NewServer initializes and returns a new Server for p. cfg may be nil.
+func NewServer(p *Pool, cfg *ServerConfig) *Server {
+	if cfg == nil {
+		cfg = &ServerConfig{}
+	}
+	return &Server{
+		pool:      p,
+		log:       slog.New(cfg.getLogHandler()).With("source", "probe.Server"),
+		listeners: map[net.Listener]struct{}{},
+		conns:     map[net.Conn]struct{}{},
+	}
+}
+
+// Serve accepts connections on l and serves each on its own goroutine until l fails or the Server is
+// closed. Serve returns ErrServerClosed after Close.
+func (s *Server) Serve(l net.Listener) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return ErrServerClosed
+	}
+	s.listeners[l] = struct{}{}
+	s.mu.Unlock()
+	s.log.Info("serving", "address", l.Addr().String())
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			s.mu.Lock()
+			closed := s.closed
+			delete(s.listeners, l)
+			s.mu.Unlock()
+			if closed {
+				return ErrServerClosed
+			}
+			return err
+		}
+		if !s.track(conn) {
+			conn.Close()
+			return ErrServerClosed
+		}
+		go s.serveConn(conn)
+	}
+}
+
+// Close closes all listeners and connections and waits for the connection goroutines to exit. Running
+// tasks are not cancelled.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	s.closed = true
+	var errs []error
+	for l := range s.listeners {
+		errs = append(errs, l.Close())
+	}
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+	s.waitGroup.Wait()
+	return errors.Join(errs...)
+}
+
+// track adds conn to the connections of the Server. track returns false if the Server is closed.
+func (s *Server) track(conn net.Conn) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return false
+	}
+	s.conns[conn] = struct{}{}
+	s.waitGroup.Add(1)
+	return true
+}
+
+// serveConn reads messages from conn until it is closed.
+func (s *Server) serveConn(conn net.Conn) {
+	defer s.waitGroup.Done()
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+		conn.Close()
+	}()
+	log := s.log.With("remote", conn.RemoteAddr().String())
+	log.Debug("accepted connection")
+	w := newRemoteWriter(conn)
+	mu := new(sync.Mutex)
+	handles := map[uint64]*TaskHandle{}
+	dec := json.NewDecoder(bufio.NewReader(conn))
+	for {
+		var msg remoteMessage
+		if err := dec.Decode(&msg); err != nil {
+			log.Debug("closed connection", "error", err)
+			return
+		}
+		switch msg.Type {
+		case remoteSubmit:
+			h, err := s.pool.SubmitDescriptor(Descriptor{Name: msg.Name, Payload: msg.Payload})
+			if err != nil {
+				reply := remoteMessage{Type: remoteReject, ID: msg.ID, Error: err.Error()}
+				if errors.Is(err, ErrUnknownTask) {
+					reply.Code = remoteCodeUnknownTask
+				}
+				w.send(reply)
+				continue
+			}
+			mu.Lock()
+			handles[msg.ID] = h
+			mu.Unlock()
+			w.send(remoteMessage{Type: remoteAck, ID: msg.ID})
+			go func(id uint64) {
+				err := h.Wait()
+				mu.Lock()
+				delete(handles, id)
+				mu.Unlock()
+				result := remoteMessage{Type: remoteResult, ID: id, Status: h.Status().String()}
+				if err != nil {
+					result.Error = err.Error()
+				}
+				// the connection may be gone, the result is then lost
+				w.send(result)
+			}(msg.ID)
+		case remoteCancel:
+			mu.Lock()
+			h := handles[msg.ID]
+			mu.Unlock()
+			if h != nil {
+				h.Cancel()
+			}
+		default:
+			log.Warn("received unknown message", "type", msg.Type)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package pool
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sync/atomic"
+	"time"
+
+	"github.com/amplify-security/probe"
+)
+
+type (
+	// ShardedPool is a collection of Pools that spreads submissions across its shards to reduce contention
+	// on a single work queue.
+	ShardedPool struct {
+		shards []*Pool
+		next   atomic.Uint64
+	}
+)
+
+// NewShardedPool initializes and returns a new ShardedPool.
+func NewShardedPool(cfg *ShardedPoolConfig) *ShardedPool {
+	shardCfg := cfg.getShard()
+	p := &ShardedPool{
+		shards: make([]*Pool, cfg.getShards()),
+	}
+	for i := range p.shards {
+		cfg := shardCfg
+		if cfg.Name != "" {
+			cfg.Name = fmt.Sprintf("%s-%d", shardCfg.Name, i)
+		}
+		p.shards[i] = NewPool(&cfg)
+	}
+	return p
+}
+
+// Shards returns the Pools that make up the ShardedPool.
+func (p *ShardedPool) Shards() []*Pool {
+	return p.shards
+}
+
+// Start starts all shards of the ShardedPool. Start returns the errors of all shards that failed to start.
+func (p *ShardedPool) Start() error {
+	errs := make([]error, 0, len(p.shards))
+	for _, s := range p.shards {
+		errs = append(errs, s.Start())
+	}
+	return errors.Join(errs...)
+}
+
+// Stop stops all shards of the ShardedPool. Stop blocks if wait is true until current work is complete.
+// Stop returns the errors of all shards that failed to stop.
+func (p *ShardedPool) Stop(wait bool) error {
+	errs := make([]error, 0, len(p.shards))
+	for _, s := range p.shards {
+		errs = append(errs, s.Stop(false))
+	}
+	if wait {
+		for _, s := range p.shards {
+			s.awaitDrained()
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Pause stops all shards of the ShardedPool from pulling further work, leaving submitted work queued.
+func (p *ShardedPool) Pause() {
+	for _, s := range p.shards {
+		s.Pause()
+	}
+}
+
+// Resume resumes pulling work on all shards of the ShardedPool after Pause.
+func (p *ShardedPool) Resume() {
+	for _, s := range p.shards {
+		s.Resume()
+	}
+}
+
+// Run executes a probe.Runner on a Probe in the ShardedPool. Shards are selected round-robin.
+func (p *ShardedPool) Run(r probe.Runner) {
+	p.roundRobin().Run(r)
+}
+
+// RunNamed is like Run, but attaches a name and labels to the Runner that are reported by InFlight while
+// it executes.
+func (p *ShardedPool) RunNamed(name string, labels map[string]string, r probe.Runner) {
+	p.roundRobin().RunNamed(name, labels, r)
+}
+
+// Submit executes a Task on a Probe in the ShardedPool and returns a TaskHandle to track or cancel it.
+// Shards are selected round-robin. Task IDs are unique within a shard.
+func (p *ShardedPool) Submit(t Task) *TaskHandle {
+	return p.roundRobin().Submit(t)
+}
+
+// SubmitNamed is like Submit, but attaches a name and labels to the Task that are reported by InFlight
+// while it executes.
+func (p *ShardedPool) SubmitNamed(name string, labels map[string]string, t Task) *TaskHandle {
+	return p.roundRobin().SubmitNamed(name, labels, t)
+}
+
+// RunKey executes a probe.Runner on a Probe in the shard selected by hashing key. Runners with the same
+// key always run on the same shard.
+func (p *ShardedPool) RunKey(key string, r probe.Runner) {
+	p.byKey(key).Run(r)
+}
+
+// RunWithDeadline executes a probe.Runner on a Probe in the ShardedPool if it is picked up before
+// deadline. Shards are selected round-robin.
+func (p *ShardedPool) RunWithDeadline(r probe.Runner, deadline time.Time) {
+	p.roundRobin().RunWithDeadline(r, deadline)
+}
+
+// roundRobin returns the next shard in round-robin order.
+func (p *ShardedPool) roundRobin() *Pool {
+	return p.shards[(p.next.Add(1)-1)%uint64(len(p.shards))]
+}
+
+// byKey returns the shard for key.
+func (p *ShardedPool) byKey(key string) *Pool {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	return p.shards[h.Sum64()%uint64(len(p.shards))]
+}
+
+// InFlight returns the work currently executing on each busy Probe across all shards.
+func (p *ShardedPool) InFlight() []probe.InFlight {
+	var inFlight []probe.InFlight
+	for _, s := range p.shards {
+		inFlight = append(inFlight, s.InFlight()...)
+	}
+	return inFlight
+}
+
+// Idle returns the number of idle Probes across all shards.
+func (p *ShardedPool) Idle() int {
+	n := 0
+	for _, s := range p.shards {
+		n += s.Idle()
+	}
+	return n
+}
+
+// Running returns the number of running Probes across all shards.
+func (p *ShardedPool) Running() int {
+	n := 0
+	for _, s := range p.shards {
+		n += s.Running()
+	}
+	return n
+}
+
+// Stats returns a snapshot of the counters aggregated across all shards.
+func (p *ShardedPool) Stats() Stats {
+	var stats Stats
+	for _, s := range p.shards {
+		ss := s.Stats()
+		stats.Size += ss.Size
+		stats.Running += ss.Running
+		stats.Idle += ss.Idle
+		stats.Queued += ss.Queued
+		stats.Expired += ss.Expired
+	}
+	return stats
+}
@@ -0,0 +1,105 @@
+package pool
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShardedPool_Run(t *testing.T) {
+	ctr := new(atomic.Int32)
+	wg := new(sync.WaitGroup)
+	wg.Add(64)
+	p := NewShardedPool(&ShardedPoolConfig{
+		Shards: 4,
+		Shard: &PoolConfig{
+			LogHandler: logHandler,
+			Size:       4,
+		},
+	})
+	assert.Len(t, p.Shards(), 4, "NewShardedPool(4) -> len(p.Shards) == 4")
+	for range 32 {
+		p.Run(func() {
+			ctr.Add(1)
+			wg.Done()
+		})
+		p.RunWithDeadline(func() {
+			ctr.Add(1)
+			wg.Done()
+		}, time.Now().Add(time.Hour))
+	}
+	wg.Wait()
+	assert.Equal(t, 64, int(ctr.Load()), "Run x64 -> ctr == 64")
+	p.Stop(true)
+	assert.Equal(t, 0, p.Running(), "Stop(true) -> p.Running == 0")
+}
+
+func TestShardedPool_RunKey(t *testing.T) {
+	p := NewShardedPool(&ShardedPoolConfig{
+		Shards: 8,
+		Shard: &PoolConfig{
+			LogHandler: logHandler,
+			Size:       1,
+		},
+	})
+	assert.Same(t, p.byKey("test"), p.byKey("test"), "byKey(test) -> same shard")
+	done := make(chan struct{})
+	p.RunKey("test", func() {
+		close(done)
+	})
+	<-done
+	p.Stop(true)
+}
+
+func TestShardedPool_Stats(t *testing.T) {
+	p := NewShardedPool(&ShardedPoolConfig{
+		Shards: 4,
+		Shard: &PoolConfig{
+			LogHandler: logHandler,
+			Size:       4,
+		},
+	})
+	for _, s := range p.Shards() {
+		for _, probe := range s.probes {
+			waitForIdle(probe)
+		}
+	}
+	assert.Equal(t, 16, p.Running(), "NewShardedPool(4x4) -> p.Running == 16")
+	assert.Equal(t, 16, p.Idle(), "NewShardedPool(4x4) -> p.Idle == 16")
+	assert.Equal(t, Stats{
+		Size:    16,
+		Running: 16,
+		Idle:    16,
+	}, p.Stats(), "NewShardedPool(4x4) -> p.Stats")
+	p.Stop(true)
+	p.Start()
+	for _, s := range p.Shards() {
+		for _, probe := range s.probes {
+			waitForRunning(probe)
+		}
+	}
+	assert.Equal(t, 16, p.Stats().Size, "Stop + Start -> p.Stats.Size == 16")
+	p.Stop(false)
+}
+
+func TestShardedPool_Pause(t *testing.T) {
+	p := NewShardedPool(&ShardedPoolConfig{
+		Shards: 2,
+		Shard: &PoolConfig{
+			LogHandler: logHandler,
+			Size:       1,
+		},
+	})
+	p.Pause()
+	for _, s := range p.Shards() {
+		assert.True(t, s.Paused(), "Pause -> s.Paused == true")
+	}
+	p.Resume()
+	for _, s := range p.Shards() {
+		assert.False(t, s.Paused(), "Resume -> s.Paused == false")
+	}
+	p.Stop(true)
+}
@@ -0,0 +1,192 @@
+package pool
+
+import (
+	"bufio"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+)
+
+type (
+	// queuedDescriptor is a Descriptor submitted with SubmitDescriptor that is waiting in the queue.
+	queuedDescriptor struct {
+		h *TaskHandle
+		d Descriptor
+	}
+
+	// queuedDescriptors tracks the Descriptors waiting in the queue of a Pool, so they can be written to a
+	// snapshot by Shutdown.
+	queuedDescriptors struct {
+		mu sync.Mutex
+		m  map[*TaskHandle]Descriptor
+	}
+)
+
+// add tracks the queued Descriptor d of the Task tracked by h.
+func (q *queuedDescriptors) add(h *TaskHandle, d Descriptor) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.m == nil {
+		q.m = map[*TaskHandle]Descriptor{}
+	}
+	q.m[h] = d
+}
+
+// remove stops tracking the Descriptor of the Task tracked by h.
+func (q *queuedDescriptors) remove(h *TaskHandle) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delete(q.m, h)
+}
+
+// list returns the Descriptors of all Tasks that are still queued, in submission order.
+func (q *queuedDescriptors) list() []queuedDescriptor {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	queued := make([]queuedDescriptor, 0, len(q.m))
+	for h, d := range q.m {
+		if h.Status() == TaskQueued {
+			queued = append(queued, queuedDescriptor{h: h, d: d})
+		}
+	}
+	slices.SortFunc(queued, func(a, b queuedDescriptor) int {
+		return cmp.Compare(a.h.ID(), b.h.ID())
+	})
+	return queued
+}
+
+// Shutdown stops the Pool like Stop(true). If path is set, Shutdown first pauses the Pool and writes the
+// Descriptors of all Tasks submitted with SubmitDescriptor that are still queued to path as JSON lines, in
+// submission order. The snapshotted Tasks are held while the snapshot is written, so a Probe that picked
+// one up just before the pause cannot start it, and are then cancelled, so they only run once restored with
+// Restore.
+// Runners and Tasks submitted as closures cannot be snapshotted and are left in the queue. If the snapshot
+// cannot be written, Shutdown resumes the Pool and returns the error without stopping it or cancelling
+// any Task.
+func (p *Pool) Shutdown(path string) error {
+	if path != "" {
+		p.Pause()
+		var (
+			held        []*TaskHandle
+			descriptors []Descriptor
+		)
+		for _, q := range p.queued.list() {
+			// a Task that started since it was listed is not snapshotted
+			if q.h.hold() {
+				held = append(held, q.h)
+				descriptors = append(descriptors, q.d)
+			}
+		}
+		if err := writeSnapshot(path, descriptors); err != nil {
+			for _, h := range held {
+				h.release()
+			}
+			p.Resume()
+			return fmt.Errorf("pool: failed to write snapshot: %w", err)
+		}
+		for _, h := range held {
+			h.Cancel()
+			p.queued.remove(h)
+		}
+		p.log.Info("wrote snapshot", "path", path, "tasks", len(descriptors))
+		if closures := p.queueLen(); closures > 0 {
+			p.log.Warn("queued work is not a descriptor and was not snapshotted", "count", closures)
+		}
+	}
+	err := p.Stop(true)
+	p.Resume()
+	return err
+}
+
+// Restore submits all Descriptors from a snapshot written by Shutdown with SubmitDescriptor, in their
+// original order, and removes the snapshot. Restore returns the number of submitted Descriptors. A missing
+// snapshot is not an error. Descriptors without a registered Handler are reported in the returned error,
+// which wraps ErrUnknownTask, and are kept in the snapshot so a later Restore can submit them.
+func (p *Pool) Restore(path string) (int, error) {
+	descriptors, err := readSnapshot(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("pool: failed to read snapshot: %w", err)
+	}
+	var (
+		errs    []error
+		pending []Descriptor
+	)
+	for _, d := range descriptors {
+		if _, err := p.SubmitDescriptor(d); err != nil {
+			errs = append(errs, err)
+			pending = append(pending, d)
+		}
+	}
+	n := len(descriptors) - len(pending)
+	if len(pending) > 0 {
+		// keep the descriptors that were not submitted for the next Restore
+		if err := writeSnapshot(path, pending); err != nil {
+			errs = append(errs, fmt.Errorf("pool: failed to rewrite snapshot: %w", err))
+		}
+	} else if err := os.Remove(path); err != nil {
+		errs = append(errs, fmt.Errorf("pool: failed to remove snapshot: %w", err))
+	}
+	p.log.Info("restored snapshot", "path", path, "tasks", n, "pending", len(pending))
+	return n, errors.Join(errs...)
+}
+
+// writeSnapshot atomically writes descriptors to path as JSON lines.
+func writeSnapshot(path string, descriptors []Descriptor) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	w := bufio.NewWriter(tmp)
+	enc := json.NewEncoder(w)
+	for _, d := range descriptors {
+		if err := enc.Encode(d); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	syncDir(filepath.Dir(path))
+	return nil
+}
+
+// readSnapshot reads the descriptors of a snapshot written by writeSnapshot.
+func readSnapshot(path string) ([]Descriptor, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var descriptors []Descriptor
+	dec := json.NewDecoder(bufio.NewReader(file))
+	for dec.More() {
+		var d Descriptor
+		if err := dec.Decode(&d); err != nil {
+			return nil, err
+		}
+		descriptors = append(descriptors, d)
+	}
+	return descriptors, nil
+}
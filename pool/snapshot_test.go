@@ -0,0 +1,141 @@
+package pool
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPool_ShutdownRestore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snapshot.jsonl")
+	mu := new(sync.Mutex)
+	var ran []string
+	tasks := NewTaskRegistry()
+	tasks.Register("record", func(_ context.Context, payload []byte) error {
+		mu.Lock()
+		defer mu.Unlock()
+		ran = append(ran, string(payload))
+		return nil
+	})
+	p := NewPool(&PoolConfig{
+		LogHandler: logHandler,
+		Size:       1,
+		Tasks:      tasks,
+	})
+	p.Pause()
+	var handles []*TaskHandle
+	for _, payload := range []string{"a", "b", "c"} {
+		h, err := p.SubmitDescriptor(Descriptor{Name: "record", Payload: []byte(payload)})
+		assert.NoError(t, err, "Pool.SubmitDescriptor -> err == nil")
+		handles = append(handles, h)
+	}
+	p.Run(func() {})
+	assert.NoError(t, p.Shutdown(path), "Pool.Shutdown -> err == nil")
+	assert.Equal(t, StateStopped, p.State(), "Pool.Shutdown -> stopped")
+	for _, h := range handles {
+		assert.ErrorIs(t, h.Wait(), ErrTaskCancelled, "Pool.Shutdown -> snapshotted tasks cancelled")
+	}
+	b, err := os.ReadFile(path)
+	assert.NoError(t, err, "os.ReadFile -> err == nil")
+	assert.Equal(t, []string{
+		`{"name":"record","payload":"YQ=="}`,
+		`{"name":"record","payload":"Yg=="}`,
+		`{"name":"record","payload":"Yw=="}`,
+	}, strings.Split(strings.TrimSpace(string(b)), "\n"), "Pool.Shutdown -> JSON lines snapshot in submission order")
+	restored := NewPool(&PoolConfig{
+		LogHandler: logHandler,
+		Size:       1,
+		Tasks:      tasks,
+	})
+	defer restored.Stop(true)
+	n, err := restored.Restore(path)
+	assert.NoError(t, err, "Pool.Restore -> err == nil")
+	assert.Equal(t, 3, n, "Pool.Restore -> 3 tasks restored")
+	done := make(chan struct{})
+	restored.Run(func() {
+		close(done)
+	})
+	<-done
+	assert.Equal(t, []string{"a", "b", "c"}, ran, "Pool.Restore -> tasks run in original order")
+	_, err = os.Stat(path)
+	assert.ErrorIs(t, err, os.ErrNotExist, "Pool.Restore -> snapshot removed")
+	n, err = restored.Restore(path)
+	assert.NoError(t, err, "Pool.Restore(missing) -> err == nil")
+	assert.Equal(t, 0, n, "Pool.Restore(missing) -> 0")
+}
+
+func TestPool_RestoreUnknown(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snapshot.jsonl")
+	snapshot := `{"name":"known","payload":null}` + "\n" + `{"name":"unknown","payload":null}` + "\n"
+	assert.NoError(t, os.WriteFile(path, []byte(snapshot), 0o600), "os.WriteFile -> err == nil")
+	tasks := NewTaskRegistry()
+	tasks.Register("known", func(_ context.Context, _ []byte) error {
+		return nil
+	})
+	p := NewPool(&PoolConfig{
+		LogHandler: logHandler,
+		Size:       1,
+		Tasks:      tasks,
+	})
+	defer p.Stop(true)
+	n, err := p.Restore(path)
+	assert.ErrorIs(t, err, ErrUnknownTask, "Pool.Restore(unknown) -> ErrUnknownTask")
+	assert.Equal(t, 1, n, "Pool.Restore(unknown) -> known task restored")
+	b, err := os.ReadFile(path)
+	assert.NoError(t, err, "Pool.Restore(unknown) -> snapshot kept")
+	assert.Equal(t, `{"name":"unknown","payload":null}`+"\n", string(b), "Pool.Restore(unknown) -> only unknown task kept")
+	// the handler is registered later, so the next Restore submits the rest
+	ran := make(chan struct{})
+	tasks.Register("unknown", func(_ context.Context, _ []byte) error {
+		close(ran)
+		return nil
+	})
+	n, err = p.Restore(path)
+	assert.NoError(t, err, "Pool.Restore(registered) -> err == nil")
+	assert.Equal(t, 1, n, "Pool.Restore(registered) -> remaining task restored")
+	<-ran
+	_, err = os.Stat(path)
+	assert.ErrorIs(t, err, os.ErrNotExist, "Pool.Restore(registered) -> snapshot removed")
+	assert.NoError(t, os.WriteFile(path, []byte("{"), 0o600), "os.WriteFile -> err == nil")
+	_, err = p.Restore(path)
+	assert.Error(t, err, "Pool.Restore(corrupt) -> err != nil")
+}
+
+func TestPool_ShutdownWithoutSnapshot(t *testing.T) {
+	p := NewPool(&PoolConfig{
+		LogHandler: logHandler,
+		Size:       1,
+	})
+	assert.NoError(t, p.Shutdown(""), "Pool.Shutdown(\"\") -> err == nil")
+	assert.Equal(t, StateStopped, p.State(), "Pool.Shutdown(\"\") -> stopped")
+	assert.ErrorIs(t, p.Shutdown(""), ErrNotRunning, "Pool.Shutdown(stopped) -> ErrNotRunning")
+}
+
+func TestPool_ShutdownWriteError(t *testing.T) {
+	tasks := NewTaskRegistry()
+	ran := make(chan struct{})
+	tasks.Register("task", func(_ context.Context, _ []byte) error {
+		close(ran)
+		return nil
+	})
+	p := NewPool(&PoolConfig{
+		LogHandler: logHandler,
+		Size:       1,
+		Tasks:      tasks,
+	})
+	defer p.Stop(true)
+	p.Pause()
+	h, err := p.SubmitDescriptor(Descriptor{Name: "task"})
+	assert.NoError(t, err, "Pool.SubmitDescriptor -> err == nil")
+	err = p.Shutdown(filepath.Join(t.TempDir(), "missing", "snapshot.jsonl"))
+	assert.Error(t, err, "Pool.Shutdown(missing directory) -> err != nil")
+	assert.False(t, p.Paused(), "Pool.Shutdown(missing directory) -> resumed")
+	assert.Equal(t, StateRunning, p.State(), "Pool.Shutdown(missing directory) -> still running")
+	<-ran
+	assert.NoError(t, h.Wait(), "Pool.Shutdown(missing directory) -> queued task not cancelled")
+}
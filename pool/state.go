@@ -0,0 +1,24 @@
+package pool
+
+import (
+	"errors"
+
+	"github.com/amplify-security/probe"
+)
+
+const (
+	StateCreated  = probe.StateCreated  // StateCreated is a Pool that has not been started yet.
+	StateRunning  = probe.StateRunning  // StateRunning is a Pool with running Probes.
+	StateDraining = probe.StateDraining // StateDraining is a stopped Pool whose Probes are finishing their current work.
+	StateStopped  = probe.StateStopped  // StateStopped is a stopped Pool with no running Probes. It can be started again.
+)
+
+type (
+	// State is the lifecycle state of a Pool.
+	State = probe.State
+)
+
+var (
+	ErrRunning    = errors.New("pool: already running") // ErrRunning is returned when starting a running Pool.
+	ErrNotRunning = errors.New("pool: not running")     // ErrNotRunning is returned when stopping a stopped Pool.
+)
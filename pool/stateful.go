@@ -0,0 +1,180 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/amplify-security/probe"
+)
+
+type (
+	// StatefulTask is a Task that receives the state of the Probe executing it.
+	StatefulTask[S any] func(ctx context.Context, state S) error
+
+	// StatefulPool is a Pool where every Probe owns a state of type S, created when the Probe starts and
+	// released when it stops. StatefulTasks receive the state of the Probe executing them, so expensive
+	// resources such as connections or buffers are reused per goroutine without synchronization.
+	StatefulPool[S any] struct {
+		*Pool
+		queue stateQueue[S]
+	}
+
+	// stateWork is Work waiting in a stateQueue. If run is set, it replaces the Runner of the Work and is
+	// called with the state of the Probe that picks the Work up.
+	stateWork[S any] struct {
+		work probe.Work
+		run  func(state S, err error)
+	}
+
+	// stateQueue is the shared work queue of a StatefulPool.
+	stateQueue[S any] chan stateWork[S]
+
+	// stateView is the probe.Queue of a single Probe in a StatefulPool. It owns the state of the Probe and is
+	// only used from the Probe goroutine.
+	stateView[S any] struct {
+		queue stateQueue[S]
+		log   *slog.Logger
+		init  func() (S, error)
+		close func(S)
+		state S
+		err   error
+		ready bool
+	}
+)
+
+var (
+	errNoState = errors.New("pool: no probe state") // errNoState is the error of StatefulTasks popped without a stateView.
+)
+
+// NewStatefulPool initializes and returns a new StatefulPool.
+func NewStatefulPool[S any](cfg *StatefulPoolConfig[S]) *StatefulPool[S] {
+	poolCfg := cfg.getPool()
+	p := &StatefulPool[S]{
+		Pool:  newPool(&poolCfg),
+		queue: make(stateQueue[S], poolCfg.getBufferSize()),
+	}
+	p.Pool.queue = p.queue
+	p.configure = func(_ int, probeCfg *probe.ProbeConfig) {
+		v := &stateView[S]{
+			queue: p.queue,
+			log:   p.log,
+			init:  cfg.getInit(),
+			close: cfg.Close,
+		}
+		probeCfg.Queue = v
+		probeCfg.OnStart = v.start
+		probeCfg.OnStop = v.stop
+	}
+	p.Start()
+	return p
+}
+
+// Submit executes a StatefulTask on a Probe in the StatefulPool and returns a TaskHandle to track or cancel
+// it. If the state of the Probe cannot be created, the StatefulTask fails with the error of Init.
+func (p *StatefulPool[S]) Submit(t StatefulTask[S]) *TaskHandle {
+	return p.SubmitNamed("", nil, t)
+}
+
+// SubmitNamed is like Submit, but attaches a name and labels to the StatefulTask that are reported by
+// InFlight while it executes.
+func (p *StatefulPool[S]) SubmitNamed(name string, labels map[string]string, t StatefulTask[S]) *TaskHandle {
+	h := p.newTaskHandle()
+	p.queue <- stateWork[S]{
+		work: probe.Work{
+			Name:   name,
+			Labels: labels,
+			Cancel: func() {
+				h.Cancel()
+			},
+		},
+		run: func(state S, err error) {
+			p.runTask(h, name, labels, func(ctx context.Context) error {
+				if err != nil {
+					return err
+				}
+				return t(ctx, state)
+			})
+		},
+	}
+	return h
+}
+
+// Push implementation of probe.Queue for stateQueue.
+func (q stateQueue[S]) Push(w probe.Work) {
+	q <- stateWork[S]{work: w}
+}
+
+// Pop implementation of probe.Queue for stateQueue. Probes of a StatefulPool pop through their stateView,
+// so StatefulTasks popped here fail without state.
+func (q stateQueue[S]) Pop(ctx context.Context) (probe.Work, bool) {
+	return q.pop(ctx, func() (S, error) {
+		var state S
+		return state, errNoState
+	})
+}
+
+// pop removes and returns the next Work, binding StatefulTasks to the state returned by acquire.
+func (q stateQueue[S]) pop(ctx context.Context, acquire func() (S, error)) (probe.Work, bool) {
+	select {
+	case <-ctx.Done():
+		return probe.Work{}, false
+	case sw := <-q:
+		w := sw.work
+		if sw.run != nil {
+			w.Runner = func() {
+				sw.run(acquire())
+			}
+		}
+		return w, true
+	}
+}
+
+// Len implementation of probe.Queue for stateQueue.
+func (q stateQueue[S]) Len() int {
+	return len(q)
+}
+
+// Push implementation of probe.Queue for stateView.
+func (v *stateView[S]) Push(w probe.Work) {
+	v.queue.Push(w)
+}
+
+// Pop implementation of probe.Queue for stateView.
+func (v *stateView[S]) Pop(ctx context.Context) (probe.Work, bool) {
+	return v.queue.pop(ctx, v.acquire)
+}
+
+// Len implementation of probe.Queue for stateView.
+func (v *stateView[S]) Len() int {
+	return v.queue.Len()
+}
+
+// start creates the state of the Probe.
+func (v *stateView[S]) start() {
+	state, err := v.init()
+	if err != nil {
+		v.log.Error("failed to initialize probe state", "error", err)
+		v.err = fmt.Errorf("pool: probe state init failed: %w", err)
+		return
+	}
+	v.state, v.err, v.ready = state, nil, true
+}
+
+// stop releases the state of the Probe.
+func (v *stateView[S]) stop() {
+	if v.ready && v.close != nil {
+		v.close(v.state)
+	}
+	var state S
+	v.state, v.err, v.ready = state, nil, false
+}
+
+// acquire returns the state of the Probe, retrying Init if it previously failed.
+func (v *stateView[S]) acquire() (S, error) {
+	if !v.ready {
+		v.start()
+	}
+	return v.state, v.err
+}
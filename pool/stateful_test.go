@@ -0,0 +1,117 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/amplify-security/probe"
+	"github.com/stretchr/testify/assert"
+)
+
+type (
+	// testState is the state of a Probe in a StatefulPool, for testing.
+	testState struct {
+		tasks int
+	}
+)
+
+func TestStatefulPool_Submit(t *testing.T) {
+	inits, closes := new(atomic.Int32), new(atomic.Int32)
+	closed := make(chan *testState, 4)
+	p := NewStatefulPool(&StatefulPoolConfig[*testState]{
+		Pool: &PoolConfig{
+			LogHandler: logHandler,
+			Size:       2,
+		},
+		Init: func() (*testState, error) {
+			inits.Add(1)
+			return &testState{}, nil
+		},
+		Close: func(s *testState) {
+			closes.Add(1)
+			closed <- s
+		},
+	})
+	handles := []*TaskHandle{}
+	for range 100 {
+		handles = append(handles, p.Submit(func(_ context.Context, s *testState) error {
+			// the state is owned by the probe goroutine, so no synchronization is needed
+			s.tasks++
+			return nil
+		}))
+	}
+	for _, h := range handles {
+		assert.NoError(t, h.Wait(), "Submit -> h.Wait == nil")
+	}
+	done := make(chan struct{})
+	p.Run(func() {
+		close(done)
+	})
+	<-done
+	p.Stop(true)
+	assert.Equal(t, 2, int(inits.Load()), "Start -> Init x2")
+	assert.Equal(t, 2, int(closes.Load()), "Stop -> Close x2")
+	states := []*testState{<-closed, <-closed}
+	assert.Equal(t, 100, states[0].tasks+states[1].tasks, "Submit x100 -> tasks == 100")
+	p.Start()
+	p.Stop(true)
+	assert.Equal(t, 4, int(inits.Load()), "Start + Stop -> Init x4")
+	assert.Equal(t, 4, int(closes.Load()), "Start + Stop -> Close x4")
+}
+
+func TestStatefulPool_Init(t *testing.T) {
+	errInit := errors.New("init")
+	inits, closes := new(atomic.Int32), new(atomic.Int32)
+	p := NewStatefulPool(&StatefulPoolConfig[int]{
+		Pool: &PoolConfig{
+			LogHandler: logHandler,
+			Size:       1,
+		},
+		Init: func() (int, error) {
+			if inits.Add(1) < 3 {
+				return 0, errInit
+			}
+			return 42, nil
+		},
+		Close: func(_ int) {
+			closes.Add(1)
+		},
+	})
+	var state int
+	task := func(_ context.Context, s int) error {
+		state = s
+		return nil
+	}
+	h := p.SubmitNamed("failed", nil, task)
+	assert.ErrorIs(t, h.Wait(), errInit, "Init(error) -> h.Wait == errInit")
+	assert.Equal(t, TaskFailed, h.Status(), "Init(error) -> h.Status == TaskFailed")
+	assert.Len(t, p.Failures(), 1, "Init(error) -> len(p.Failures) == 1")
+	assert.NoError(t, p.Submit(task).Wait(), "Init(retry) -> h.Wait == nil")
+	assert.Equal(t, 42, state, "Init(retry) -> state == 42")
+	p.Stop(true)
+	assert.Equal(t, 1, int(closes.Load()), "Stop -> Close x1")
+}
+
+func TestStateQueue_Pop(t *testing.T) {
+	q := make(stateQueue[int], 2)
+	var err error
+	q <- stateWork[int]{
+		run: func(_ int, e error) {
+			err = e
+		},
+	}
+	q.Push(probe.Work{Name: "stateless"})
+	assert.Equal(t, 2, q.Len(), "Push x2 -> q.Len == 2")
+	w, ok := q.Pop(context.Background())
+	assert.True(t, ok, "Pop -> ok == true")
+	w.Runner()
+	assert.ErrorIs(t, err, errNoState, "Pop(stateful) -> errNoState")
+	w, _ = q.Pop(context.Background())
+	assert.Equal(t, "stateless", w.Name, "Pop(stateless) -> w.Name == stateless")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, ok = q.Pop(ctx)
+	assert.False(t, ok, "Pop(cancelled ctx) -> ok == false")
+}
@@ -0,0 +1,218 @@
+package pool
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/amplify-security/probe"
+)
+
+type (
+	// deque is a bounded double-ended queue of Work owned by a single Probe. The owner pops from the
+	// front and other Probes steal from the back.
+	deque struct {
+		mu   sync.Mutex
+		buf  []dequeItem
+		head int
+		n    int
+	}
+
+	// dequeItem is Work waiting in a deque, with the TaskHandle of the Task it runs if it was pushed with
+	// PushTask.
+	dequeItem struct {
+		work probe.Work
+		h    *TaskHandle
+	}
+
+	// stealQueue is a probe.Queue that spreads Work across per-Probe deques. Probes pop from their
+	// own deque first and steal from the other deques when their own is empty, so Probes only contend
+	// with each other when the Pool is unbalanced.
+	stealQueue struct {
+		deques []*deque
+		next   atomic.Uint64
+		ready  *parking
+		space  *parking
+	}
+
+	// localQueue is the probe.Queue view of a stealQueue used by a single Probe.
+	localQueue struct {
+		*stealQueue
+		id int
+	}
+)
+
+// newDeque initializes and returns a new deque that holds at most size Work items.
+func newDeque(size int) *deque {
+	return &deque{
+		buf: make([]dequeItem, size),
+	}
+}
+
+// pushBack adds an item to the back of the deque. pushBack returns false if the deque is full.
+func (d *deque) pushBack(item dequeItem) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.n == len(d.buf) {
+		return false
+	}
+	d.buf[(d.head+d.n)%len(d.buf)] = item
+	d.n++
+	return true
+}
+
+// popFront removes and returns the Work at the front of the deque.
+func (d *deque) popFront() (probe.Work, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.n == 0 {
+		return probe.Work{}, false
+	}
+	w := d.buf[d.head].work
+	d.buf[d.head] = dequeItem{}
+	d.head = (d.head + 1) % len(d.buf)
+	d.n--
+	return w, true
+}
+
+// popBack removes and returns the Work at the back of the deque.
+func (d *deque) popBack() (probe.Work, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.n == 0 {
+		return probe.Work{}, false
+	}
+	i := (d.head + d.n - 1) % len(d.buf)
+	w := d.buf[i].work
+	d.buf[i] = dequeItem{}
+	d.n--
+	return w, true
+}
+
+// remove removes the item of the Task tracked by h, keeping the order of the other items. remove returns
+// false if the Task is not in the deque.
+func (d *deque) remove(h *TaskHandle) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for i := range d.n {
+		if d.buf[(d.head+i)%len(d.buf)].h != h {
+			continue
+		}
+		for ; i < d.n-1; i++ {
+			d.buf[(d.head+i)%len(d.buf)] = d.buf[(d.head+i+1)%len(d.buf)]
+		}
+		d.buf[(d.head+d.n-1)%len(d.buf)] = dequeItem{}
+		d.n--
+		return true
+	}
+	return false
+}
+
+// len returns the number of Work items in the deque.
+func (d *deque) len() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.n
+}
+
+// newStealQueue initializes and returns a new stealQueue with a deque for each of count Probes. The
+// total capacity of the deques is at least size.
+func newStealQueue(count, size int) *stealQueue {
+	per := (size + count - 1) / count
+	q := &stealQueue{
+		deques: make([]*deque, count),
+		ready:  newParking(count),
+		space:  newParking(count),
+	}
+	for i := range q.deques {
+		q.deques[i] = newDeque(per)
+	}
+	return q
+}
+
+// local returns the probe.Queue for the Probe with the given index.
+func (q *stealQueue) local(id int) probe.Queue {
+	return &localQueue{
+		stealQueue: q,
+		id:         id,
+	}
+}
+
+// Push implementation of probe.Queue for stealQueue. Work is distributed round-robin across the deques,
+// skipping full deques. Push blocks while all deques are full.
+func (q *stealQueue) Push(w probe.Work) {
+	q.push(dequeItem{work: w})
+}
+
+// PushTask implementation of taskQueue for stealQueue.
+func (q *stealQueue) PushTask(h *TaskHandle, w probe.Work) {
+	q.push(dequeItem{work: w, h: h})
+	h.setRemove(func() bool {
+		return q.remove(h)
+	})
+}
+
+// push adds an item to one of the deques, blocking while all deques are full.
+func (q *stealQueue) push(item dequeItem) {
+	wait(q.space, context.Background(), func() (struct{}, bool) {
+		return struct{}{}, q.tryPush(item)
+	})
+	q.ready.notify()
+}
+
+// tryPush attempts to add an item to one of the deques without blocking.
+func (q *stealQueue) tryPush(item dequeItem) bool {
+	start := int(q.next.Add(1) % uint64(len(q.deques)))
+	for i := range q.deques {
+		if q.deques[(start+i)%len(q.deques)].pushBack(item) {
+			return true
+		}
+	}
+	return false
+}
+
+// remove removes the Task tracked by h from the deque holding it and wakes up a blocked Push. remove
+// returns false if the Task has already been popped.
+func (q *stealQueue) remove(h *TaskHandle) bool {
+	for _, d := range q.deques {
+		if d.remove(h) {
+			q.space.notify()
+			return true
+		}
+	}
+	return false
+}
+
+// Pop implementation of probe.Queue for stealQueue. Consumers without a deque of their own start looking
+// for work at a round-robin deque.
+func (q *stealQueue) Pop(ctx context.Context) (probe.Work, bool) {
+	return q.local(int(q.next.Add(1) % uint64(len(q.deques)))).Pop(ctx)
+}
+
+// Len implementation of probe.Queue for stealQueue.
+func (q *stealQueue) Len() int {
+	n := 0
+	for _, d := range q.deques {
+		n += d.len()
+	}
+	return n
+}
+
+// Pop implementation of probe.Queue for localQueue. Pop parks the calling Probe when there is no work to
+// pop or steal.
+func (q *localQueue) Pop(ctx context.Context) (probe.Work, bool) {
+	w, ok := wait(q.ready, ctx, q.take)
+	if ok {
+		q.space.notify()
+	}
+	return w, ok
+}
+
+// take pops Work from the Probe's own deque, or steals it from another Probe's deque.
+func (q *localQueue) take() (probe.Work, bool) {
+	w, ok := q.deques[q.id].popFront()
+	for i := 1; !ok && i < len(q.deques); i++ {
+		w, ok = q.deques[(q.id+i)%len(q.deques)].popBack()
+	}
+	return w, ok
+}
@@ -0,0 +1,139 @@
+package pool
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/amplify-security/probe"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeque(t *testing.T) {
+	order := []int{}
+	d := newDeque(3)
+	for i := range 4 {
+		ok := d.pushBack(dequeItem{work: probe.Work{Runner: func() {
+			order = append(order, i)
+		}}})
+		assert.Equal(t, i < 3, ok, "pushBack -> ok == !full")
+	}
+	assert.Equal(t, 3, d.len(), "pushBack x3 -> d.len == 3")
+	w, _ := d.popBack()
+	w.Runner()
+	w, _ = d.popFront()
+	w.Runner()
+	w, _ = d.popFront()
+	w.Runner()
+	_, ok := d.popFront()
+	assert.False(t, ok, "popFront(empty) -> ok == false")
+	_, ok = d.popBack()
+	assert.False(t, ok, "popBack(empty) -> ok == false")
+	assert.Equal(t, []int{2, 0, 1}, order, "popBack, popFront x2 -> [2, 0, 1]")
+}
+
+func TestDeque_Remove(t *testing.T) {
+	order := []int{}
+	d := newDeque(3)
+	hs := []*TaskHandle{newTaskHandle(1), newTaskHandle(2), newTaskHandle(3)}
+	// the deque wraps around, so removal shifts items across the end of the buffer
+	d.pushBack(dequeItem{})
+	d.popFront()
+	for i, h := range hs {
+		d.pushBack(dequeItem{work: probe.Work{Runner: func() {
+			order = append(order, i)
+		}}, h: h})
+	}
+	assert.True(t, d.remove(hs[1]), "remove(queued) -> true")
+	assert.False(t, d.remove(hs[1]), "remove(removed) -> false")
+	assert.Equal(t, 2, d.len(), "remove -> d.len == 2")
+	for range 2 {
+		w, _ := d.popFront()
+		w.Runner()
+	}
+	assert.Equal(t, []int{0, 2}, order, "remove(1) -> [0, 2]")
+}
+
+func TestStealQueue_PushTask(t *testing.T) {
+	q := newStealQueue(2, 2)
+	h := newTaskHandle(1)
+	q.PushTask(h, probe.Work{Runner: func() {}})
+	q.Push(probe.Work{Runner: func() {}})
+	assert.True(t, h.Cancel(), "Cancel(queued) -> true")
+	assert.Equal(t, 1, q.Len(), "Cancel(queued) -> q.Len == 1")
+	assert.False(t, q.remove(h), "remove(removed) -> false")
+}
+
+func TestStealQueue_Pop(t *testing.T) {
+	q := newStealQueue(4, 8)
+	for range 8 {
+		q.Push(probe.Work{Runner: func() {}})
+	}
+	assert.Equal(t, 8, q.Len(), "Push x8 -> q.Len == 8")
+	// a single local queue must be able to steal all work from the other deques
+	local := q.local(0)
+	ctx, cancel := context.WithCancel(context.Background())
+	for range 8 {
+		_, ok := local.Pop(ctx)
+		assert.True(t, ok, "Pop -> ok == true")
+	}
+	assert.Equal(t, 0, q.Len(), "Pop x8 -> q.Len == 0")
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		q.Push(probe.Work{Runner: func() {}})
+	}()
+	_, ok := q.Pop(ctx)
+	assert.True(t, ok, "Pop(parked) + Push -> ok == true")
+	cancel()
+	_, ok = local.Pop(ctx)
+	assert.False(t, ok, "Pop(cancelled ctx) -> ok == false")
+}
+
+func TestStealQueue_Push(t *testing.T) {
+	q := newStealQueue(2, 2)
+	q.Push(probe.Work{Runner: func() {}})
+	q.Push(probe.Work{Runner: func() {}})
+	pushed := make(chan struct{})
+	go func() {
+		// the queue is full, so this blocks until Work is popped
+		q.Push(probe.Work{Runner: func() {}})
+		close(pushed)
+	}()
+	select {
+	case <-pushed:
+		t.Error("Push(full) -> did not block")
+	case <-time.After(10 * time.Millisecond):
+	}
+	_, ok := q.local(1).Pop(context.Background())
+	assert.True(t, ok, "Pop -> ok == true")
+	<-pushed
+	assert.Equal(t, 2, q.Len(), "Pop + Push(blocked) -> q.Len == 2")
+}
+
+func TestPool_RunWorkStealing(t *testing.T) {
+	ctr := new(atomic.Int32)
+	wg := new(sync.WaitGroup)
+	wg.Add(1024)
+	p := NewPool(&PoolConfig{
+		LogHandler: logHandler,
+		Size:       8,
+		BufferSize: 16,
+		Scheduling: SchedulingWorkStealing,
+	})
+	for range 1024 {
+		p.Run(func() {
+			ctr.Add(1)
+			wg.Done()
+		})
+	}
+	wg.Wait()
+	assert.Equal(t, 1024, int(ctr.Load()), "Run x1024 -> ctr == 1024")
+	_, ok := p.probes[0].Queue().(*localQueue)
+	assert.True(t, ok, "SchedulingWorkStealing -> probe.Queue is *localQueue")
+	p.Stop(true)
+}
+
+// ensure localQueue satisfies probe.Queue
+var _ probe.Queue = (*localQueue)(nil)
@@ -0,0 +1,250 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/amplify-security/probe"
+)
+
+const (
+	TaskQueued    TaskStatus = iota // TaskQueued is a Task waiting for a Probe.
+	TaskRunning                     // TaskRunning is a Task executing on a Probe.
+	TaskDone                        // TaskDone is a Task that returned without error.
+	TaskFailed                      // TaskFailed is a Task that returned an error.
+	TaskCancelled                   // TaskCancelled is a Task that was cancelled before or while running.
+)
+
+type (
+	// Task is a function submitted to a Pool with Submit. The context is canceled when the Task is cancelled
+	// or the Pool is stopped.
+	Task func(ctx context.Context) error
+
+	// TaskStatus is the status of a submitted Task.
+	TaskStatus int
+
+	// TaskHandle tracks a Task submitted to a Pool.
+	TaskHandle struct {
+		id        uint64
+		mu        sync.Mutex
+		status    TaskStatus
+		err       error
+		submitted time.Time
+		started   time.Time
+		finished  time.Time
+		cancelled bool
+		cancel    context.CancelFunc
+		done      chan struct{}
+		held      chan struct{}      // held is closed when a hold on the queued Task ends, nil without a hold.
+		remove    func() bool        // remove takes the queued Task out of its queue and reports whether it did. Optional.
+		onCancel  func(removed bool) // onCancel is called when the Task is cancelled while queued. Optional.
+	}
+
+	// taskQueue is a probe.Queue that removes Tasks cancelled while queued, freeing their slot.
+	taskQueue interface {
+		probe.Queue
+		// PushTask is like Push for the Work of the Task tracked by h, and removes it if h is cancelled
+		// while it waits.
+		PushTask(h *TaskHandle, w probe.Work)
+	}
+)
+
+var (
+	ErrTaskCancelled = errors.New("pool: task cancelled") // ErrTaskCancelled is the error of a Task cancelled while queued.
+)
+
+// String implementation of fmt.Stringer for TaskStatus.
+func (s TaskStatus) String() string {
+	switch s {
+	case TaskQueued:
+		return "queued"
+	case TaskRunning:
+		return "running"
+	case TaskDone:
+		return "done"
+	case TaskFailed:
+		return "failed"
+	case TaskCancelled:
+		return "cancelled"
+	default:
+		return "unknown"
+	}
+}
+
+// newTaskHandle initializes and returns a new queued TaskHandle.
+func newTaskHandle(id uint64) *TaskHandle {
+	return &TaskHandle{
+		id:        id,
+		status:    TaskQueued,
+		submitted: time.Now(),
+		done:      make(chan struct{}),
+	}
+}
+
+// ID returns the identifier of the Task, unique within its Pool.
+func (h *TaskHandle) ID() uint64 {
+	return h.id
+}
+
+// Status returns the current status of the Task.
+func (h *TaskHandle) Status() TaskStatus {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.status
+}
+
+// Err returns the error of a finished Task.
+func (h *TaskHandle) Err() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.err
+}
+
+// Submitted returns the time the Task was submitted.
+func (h *TaskHandle) Submitted() time.Time {
+	return h.submitted
+}
+
+// Started returns the time a Probe started the Task, or the zero time if it has not started.
+func (h *TaskHandle) Started() time.Time {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.started
+}
+
+// Finished returns the time the Task finished or was cancelled, or the zero time if it has not finished.
+func (h *TaskHandle) Finished() time.Time {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.finished
+}
+
+// Done returns a channel that is closed when the Task finishes or is cancelled.
+func (h *TaskHandle) Done() <-chan struct{} {
+	return h.done
+}
+
+// Wait blocks until the Task finishes or is cancelled and returns its error.
+func (h *TaskHandle) Wait() error {
+	<-h.done
+	return h.Err()
+}
+
+// Cancel cancels the Task. A queued Task finishes immediately and is removed from the queue, freeing its
+// slot. Queues that cannot remove Work, the QueueRing and QueueChan queues, skip the Task when a Probe
+// picks it up instead, and it occupies its slot until then. A running Task has its context canceled and is
+// marked cancelled when it returns. Cancel returns false if the Task has already finished.
+func (h *TaskHandle) Cancel() bool {
+	h.mu.Lock()
+	switch h.status {
+	case TaskQueued:
+		h.cancelled = true
+		h.finishLocked(ErrTaskCancelled)
+		h.releaseLocked()
+		remove, onCancel := h.remove, h.onCancel
+		h.mu.Unlock()
+		// the queue is not locked under h.mu, as queues may look at the status of their Tasks
+		removed := remove != nil && remove()
+		if onCancel != nil {
+			onCancel(removed)
+		}
+		return true
+	case TaskRunning:
+		h.cancelled = true
+		h.cancel()
+		h.mu.Unlock()
+		return true
+	default:
+		h.mu.Unlock()
+		return false
+	}
+}
+
+// hold keeps a queued Task from starting until the hold is released or the Task is cancelled. A Probe that
+// picks up the Task waits for either. hold returns false if the Task is no longer queued or already held.
+func (h *TaskHandle) hold() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.status != TaskQueued || h.held != nil {
+		return false
+	}
+	h.held = make(chan struct{})
+	return true
+}
+
+// release ends a hold on the Task.
+func (h *TaskHandle) release() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.releaseLocked()
+}
+
+// releaseLocked ends a hold on the Task, if any. The caller must hold h.mu.
+func (h *TaskHandle) releaseLocked() {
+	if h.held != nil {
+		close(h.held)
+		h.held = nil
+	}
+}
+
+// setRemove sets the function that removes the queued Task from its queue when it is cancelled.
+func (h *TaskHandle) setRemove(remove func() bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.remove = remove
+}
+
+// pushTask adds the Work of the Task tracked by h to q, through PushTask if q can remove cancelled Tasks.
+func pushTask(q probe.Queue, h *TaskHandle, w probe.Work) {
+	if tq, ok := q.(taskQueue); ok {
+		tq.PushTask(h, w)
+		return
+	}
+	q.Push(w)
+}
+
+// start moves the Task to TaskRunning with a context derived from ctx, waiting while the Task is held.
+// start returns false if the Task was cancelled while queued.
+func (h *TaskHandle) start(ctx context.Context) (context.Context, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for h.held != nil {
+		held := h.held
+		h.mu.Unlock()
+		<-held
+		h.mu.Lock()
+	}
+	if h.status != TaskQueued {
+		return nil, false
+	}
+	ctx, h.cancel = context.WithCancel(ctx)
+	h.status = TaskRunning
+	h.started = time.Now()
+	return ctx, true
+}
+
+// finish moves a running Task to its final status and returns it.
+func (h *TaskHandle) finish(err error) TaskStatus {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.cancel()
+	h.finishLocked(err)
+	return h.status
+}
+
+// finishLocked records the result of the Task and closes done. The caller must hold h.mu.
+func (h *TaskHandle) finishLocked(err error) {
+	switch {
+	case h.cancelled:
+		h.status = TaskCancelled
+	case err != nil:
+		h.status = TaskFailed
+	default:
+		h.status = TaskDone
+	}
+	h.err = err
+	h.finished = time.Now()
+	close(h.done)
+}
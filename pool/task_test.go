@@ -0,0 +1,228 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTaskStatus_String(t *testing.T) {
+	cases := []struct {
+		s   TaskStatus
+		e   string
+		msg string
+	}{
+		{
+			s:   TaskQueued,
+			e:   "queued",
+			msg: "TaskQueued.String -> queued",
+		},
+		{
+			s:   TaskRunning,
+			e:   "running",
+			msg: "TaskRunning.String -> running",
+		},
+		{
+			s:   TaskDone,
+			e:   "done",
+			msg: "TaskDone.String -> done",
+		},
+		{
+			s:   TaskFailed,
+			e:   "failed",
+			msg: "TaskFailed.String -> failed",
+		},
+		{
+			s:   TaskCancelled,
+			e:   "cancelled",
+			msg: "TaskCancelled.String -> cancelled",
+		},
+		{
+			s:   TaskStatus(-1),
+			e:   "unknown",
+			msg: "TaskStatus(-1).String -> unknown",
+		},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.e, c.s.String(), c.msg)
+	}
+}
+
+func TestPool_Submit(t *testing.T) {
+	errTest := errors.New("test")
+	p := NewPool(&PoolConfig{
+		LogHandler: logHandler,
+		Size:       1,
+	})
+	h1 := p.Submit(func(_ context.Context) error {
+		return nil
+	})
+	h2 := p.Submit(func(_ context.Context) error {
+		return errTest
+	})
+	assert.NotEqual(t, h1.ID(), h2.ID(), "Submit x2 -> unique IDs")
+	assert.NoError(t, h1.Wait(), "Submit(nil) -> h.Wait == nil")
+	assert.Equal(t, TaskDone, h1.Status(), "Submit(nil) -> TaskDone")
+	assert.ErrorIs(t, h2.Wait(), errTest, "Submit(errTest) -> h.Wait == errTest")
+	assert.Equal(t, TaskFailed, h2.Status(), "Submit(errTest) -> TaskFailed")
+	assert.False(t, h1.Submitted().After(h1.Started()), "h.Submitted <= h.Started")
+	assert.False(t, h1.Started().After(h1.Finished()), "h.Started <= h.Finished")
+	assert.False(t, h1.Cancel(), "Cancel(done) -> false")
+	p.Stop(true)
+}
+
+func TestTaskHandle_Cancel(t *testing.T) {
+	p := NewPool(&PoolConfig{
+		LogHandler: logHandler,
+		Size:       1,
+	})
+	started := make(chan struct{})
+	running := p.Submit(func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	queued := p.Submit(func(_ context.Context) error {
+		t.Error("cancelled task executed")
+		return nil
+	})
+	<-started
+	assert.Equal(t, TaskRunning, running.Status(), "Submit -> TaskRunning")
+	assert.False(t, running.Started().IsZero(), "TaskRunning -> h.Started != 0")
+	assert.Equal(t, TaskQueued, queued.Status(), "Submit(busy pool) -> TaskQueued")
+	assert.True(t, queued.Started().IsZero(), "TaskQueued -> h.Started == 0")
+	assert.True(t, queued.Cancel(), "Cancel(queued) -> true")
+	assert.ErrorIs(t, queued.Wait(), ErrTaskCancelled, "Cancel(queued) -> ErrTaskCancelled")
+	assert.Equal(t, TaskCancelled, queued.Status(), "Cancel(queued) -> TaskCancelled")
+	assert.True(t, running.Cancel(), "Cancel(running) -> true")
+	assert.ErrorIs(t, running.Wait(), context.Canceled, "Cancel(running) -> context.Canceled")
+	assert.Equal(t, TaskCancelled, running.Status(), "Cancel(running) -> TaskCancelled")
+	<-running.Done()
+	assert.False(t, running.Finished().IsZero(), "TaskCancelled -> h.Finished != 0")
+	// the cancelled queued task is skipped by the probe
+	done := p.Submit(func(_ context.Context) error {
+		return nil
+	})
+	assert.NoError(t, done.Wait(), "Submit after Cancel -> nil")
+	p.Stop(true)
+}
+
+func TestTaskHandle_CancelQueued(t *testing.T) {
+	tests := []struct {
+		name  string
+		cfg   PoolConfig
+		frees bool
+	}{
+		{"chan", PoolConfig{}, false},
+		{"ring", PoolConfig{Queue: QueueRing}, false},
+		{"edf", PoolConfig{Scheduling: SchedulingEDF}, true},
+		{"stealing", PoolConfig{Scheduling: SchedulingWorkStealing}, true},
+		{"dedup edf", PoolConfig{Scheduling: SchedulingEDF, Dedup: DedupDrop}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := tt.cfg
+			cfg.LogHandler = logHandler
+			cfg.Size = 1
+			cfg.BufferSize = 2
+			p := NewPool(&cfg)
+			defer p.Stop(true)
+			ctrl, started := make(chan struct{}), make(chan struct{})
+			running := p.Submit(func(_ context.Context) error {
+				close(started)
+				<-ctrl
+				return nil
+			})
+			<-started
+			var queued []*TaskHandle
+			for range 2 {
+				queued = append(queued, p.Submit(func(_ context.Context) error {
+					t.Error("cancelled task executed")
+					return nil
+				}))
+			}
+			assert.Equal(t, 2, p.Stats().Queued, "Submit x2 (busy pool) -> Stats.Queued == 2")
+			for _, h := range queued {
+				assert.True(t, h.Cancel(), "Cancel(queued) -> true")
+			}
+			assert.Equal(t, 0, p.Stats().Queued, "Cancel(queued) x2 -> Stats.Queued == 0")
+			ran := make(chan struct{})
+			pushed := make(chan struct{})
+			go func() {
+				p.Run(func() {
+					close(ran)
+				})
+				close(pushed)
+			}()
+			if tt.frees {
+				select {
+				case <-pushed:
+				case <-time.After(time.Second):
+					t.Error("Run(queue of cancelled tasks) -> blocked")
+				}
+			} else {
+				// the cancelled tasks still occupy the buffer until the probe skips them
+				select {
+				case <-pushed:
+					t.Error("Run(full queue of cancelled tasks) -> did not block")
+				case <-time.After(20 * time.Millisecond):
+				}
+			}
+			close(ctrl)
+			assert.NoError(t, running.Wait(), "running.Wait -> nil")
+			<-pushed
+			<-ran
+			assert.Equal(t, 0, p.Stats().Queued, "skip cancelled tasks -> Stats.Queued == 0")
+			assert.Equal(t, int64(0), p.skipCtr.Load(), "skip cancelled tasks -> no cancelled tasks left")
+		})
+	}
+}
+
+func TestTaskHandle_Hold(t *testing.T) {
+	tests := []struct {
+		name    string
+		end     func(h *TaskHandle)
+		started bool
+	}{
+		{"release", (*TaskHandle).release, true},
+		{"cancel", func(h *TaskHandle) { h.Cancel() }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTaskHandle(1)
+			assert.True(t, h.hold(), "hold(queued) -> true")
+			assert.False(t, h.hold(), "hold(held) -> false")
+			result := make(chan bool)
+			go func() {
+				_, ok := h.start(context.Background())
+				result <- ok
+			}()
+			select {
+			case <-result:
+				t.Error("start(held) -> did not wait")
+			case <-time.After(20 * time.Millisecond):
+			}
+			tt.end(h)
+			assert.Equal(t, tt.started, <-result, "start(held) -> waits for the hold to end")
+			assert.False(t, h.hold(), "hold(not queued) -> false")
+		})
+	}
+}
+
+func TestShardedPool_Submit(t *testing.T) {
+	p := NewShardedPool(&ShardedPoolConfig{
+		Shards: 2,
+		Shard: &PoolConfig{
+			LogHandler: logHandler,
+			Size:       1,
+		},
+	})
+	h := p.Submit(func(_ context.Context) error {
+		return nil
+	})
+	assert.NoError(t, h.Wait(), "Submit -> nil")
+	p.Stop(true)
+}
@@ -0,0 +1,146 @@
+package pool
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/amplify-security/probe"
+)
+
+const (
+	StuckWarn StuckLevel = iota // StuckWarn is work running longer than WatchdogConfig.Warn.
+	StuckHard                   // StuckHard is work running longer than WatchdogConfig.Hard.
+)
+
+type (
+	// StuckLevel is the threshold exceeded by stuck work.
+	StuckLevel int
+
+	// Stuck is a report of stuck work by the watchdog of a Pool.
+	Stuck struct {
+		InFlight  probe.InFlight // InFlight is the stuck work.
+		Level     StuckLevel     // Level is the threshold exceeded by the work.
+		Stack     []byte         // Stack is the stack trace of the Probe goroutine running the work.
+		Cancelled bool           // Cancelled is true if the watchdog cancelled the work.
+	}
+
+	// stuckKey identifies a single execution of work on a Probe.
+	stuckKey struct {
+		probeID string
+		started time.Time
+	}
+
+	// watchdog periodically scans the Probes of a Pool for stuck work.
+	watchdog struct {
+		log      *slog.Logger
+		interval time.Duration
+		warn     time.Duration
+		hard     time.Duration
+		cancel   bool
+		onStuck  func(Stuck)
+	}
+)
+
+// String implementation of fmt.Stringer for StuckLevel.
+func (l StuckLevel) String() string {
+	switch l {
+	case StuckWarn:
+		return "warn"
+	case StuckHard:
+		return "hard"
+	default:
+		return "unknown"
+	}
+}
+
+// newWatchdog initializes and returns a new watchdog.
+func newWatchdog(cfg *WatchdogConfig, log *slog.Logger) *watchdog {
+	return &watchdog{
+		log:      log,
+		interval: cfg.getInterval(),
+		warn:     cfg.Warn,
+		hard:     cfg.Hard,
+		cancel:   cfg.Cancel,
+		onStuck:  cfg.OnStuck,
+	}
+}
+
+// run scans probes every interval until ctx is done.
+func (w *watchdog) run(ctx context.Context, probes []*probe.Probe) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	// every execution of work is reported at most once per level
+	reported := map[stuckKey]StuckLevel{}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			reported = w.scan(probes, reported)
+		}
+	}
+}
+
+// scan reports stuck work on probes that has not been reported at its level yet. scan returns the levels
+// reported for work that is still in flight.
+func (w *watchdog) scan(probes []*probe.Probe, reported map[stuckKey]StuckLevel) map[stuckKey]StuckLevel {
+	stuck := map[stuckKey]StuckLevel{}
+	for _, p := range probes {
+		inFlight, ok := p.InFlight()
+		if !ok {
+			continue
+		}
+		level, ok := w.level(inFlight.Elapsed)
+		if !ok {
+			continue
+		}
+		key := stuckKey{probeID: inFlight.ProbeID, started: inFlight.Started}
+		stuck[key] = level
+		if prev, ok := reported[key]; ok && prev >= level {
+			continue
+		}
+		w.report(p, inFlight, level)
+	}
+	return stuck
+}
+
+// level returns the threshold exceeded by work that has been running for elapsed.
+func (w *watchdog) level(elapsed time.Duration) (StuckLevel, bool) {
+	switch {
+	case w.hard != 0 && elapsed >= w.hard:
+		return StuckHard, true
+	case w.warn != 0 && elapsed >= w.warn:
+		return StuckWarn, true
+	default:
+		return 0, false
+	}
+}
+
+// report logs stuck work, cancels it if configured and calls onStuck.
+func (w *watchdog) report(p *probe.Probe, inFlight probe.InFlight, level StuckLevel) {
+	s := Stuck{
+		InFlight: inFlight,
+		Level:    level,
+		Stack:    p.Stack(),
+	}
+	if level == StuckHard && w.cancel {
+		s.Cancelled = p.CancelInFlight()
+	}
+	logLevel := slog.LevelWarn
+	if level == StuckHard {
+		logLevel = slog.LevelError
+	}
+	w.log.Log(context.Background(), logLevel, "stuck work",
+		"probe", inFlight.ProbeID,
+		"name", inFlight.Name,
+		"labels", inFlight.Labels,
+		"elapsed", inFlight.Elapsed,
+		"level", level.String(),
+		"cancelled", s.Cancelled,
+		"stack", string(s.Stack),
+	)
+	if w.onStuck != nil {
+		w.onStuck(s)
+	}
+}
@@ -0,0 +1,71 @@
+package pool
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStuckLevel_String(t *testing.T) {
+	cases := []struct {
+		l   StuckLevel
+		s   string
+		msg string
+	}{
+		{
+			l:   StuckWarn,
+			s:   "warn",
+			msg: "StuckWarn.String -> warn",
+		},
+		{
+			l:   StuckHard,
+			s:   "hard",
+			msg: "StuckHard.String -> hard",
+		},
+		{
+			l:   StuckLevel(-1),
+			s:   "unknown",
+			msg: "StuckLevel(-1).String -> unknown",
+		},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.s, c.l.String(), c.msg)
+	}
+}
+
+func TestPool_Watchdog(t *testing.T) {
+	reports := make(chan Stuck, 8)
+	p := NewPool(&PoolConfig{
+		LogHandler: logHandler,
+		Size:       1,
+		Watchdog: &WatchdogConfig{
+			Interval: 5 * time.Millisecond,
+			Warn:     20 * time.Millisecond,
+			Hard:     60 * time.Millisecond,
+			Cancel:   true,
+			OnStuck: func(s Stuck) {
+				reports <- s
+			},
+		},
+	})
+	h := p.SubmitNamed("hung", map[string]string{"key": "value"}, func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	warn := <-reports
+	assert.Equal(t, StuckWarn, warn.Level, "Warn exceeded -> s.Level == StuckWarn")
+	assert.Equal(t, "hung", warn.InFlight.Name, "Warn exceeded -> s.InFlight.Name == hung")
+	assert.GreaterOrEqual(t, warn.InFlight.Elapsed, 20*time.Millisecond, "Warn exceeded -> s.InFlight.Elapsed >= Warn")
+	assert.Contains(t, string(warn.Stack), "TestPool_Watchdog", "Warn exceeded -> s.Stack contains Task")
+	assert.False(t, warn.Cancelled, "Warn exceeded -> s.Cancelled == false")
+	hard := <-reports
+	assert.Equal(t, StuckHard, hard.Level, "Hard exceeded -> s.Level == StuckHard")
+	assert.True(t, hard.Cancelled, "Hard exceeded -> s.Cancelled == true")
+	assert.ErrorIs(t, h.Wait(), context.Canceled, "Hard exceeded -> h.Wait == context.Canceled")
+	assert.Equal(t, TaskCancelled, h.Status(), "Hard exceeded -> h.Status == TaskCancelled")
+	time.Sleep(20 * time.Millisecond)
+	assert.Empty(t, reports, "Hard exceeded -> no further reports")
+	p.Stop(true)
+}
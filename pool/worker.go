@@ -0,0 +1,164 @@
+package pool
+
+import (
+	"context"
+
+	"github.com/amplify-security/probe"
+)
+
+type (
+	// Result is the output of a Worker for a single input.
+	Result[In, Out any] struct {
+		In  In    // In is the input.
+		Out Out   // Out is the output of WorkerConfig.Process.
+		Err error // Err is the error of WorkerConfig.Process. Panics are reported as a *PanicError.
+	}
+
+	// Worker is a Pool that runs a single processing function on a stream of inputs and delivers typed
+	// results. Inputs are queued as values, so processing them does not allocate a closure per input.
+	Worker[In, Out any] struct {
+		*Pool
+		queue   *workerQueue[In]
+		process func(ctx context.Context, in In) (Out, error)
+		results chan Result[In, Out]
+	}
+
+	// workerItem is an input or Work waiting in a workerQueue. Work is set if the item was pushed with Run.
+	workerItem[In any] struct {
+		in   In
+		work probe.Work
+	}
+
+	// workerQueue is the shared work queue of a Worker.
+	workerQueue[In any] struct {
+		items chan workerItem[In]
+		run   func(in In)
+	}
+
+	// workerView is the probe.Queue of a single Probe in a Worker. Its Runner processes the last popped
+	// input and is only used from the Probe goroutine.
+	workerView[In any] struct {
+		queue  *workerQueue[In]
+		in     In
+		runner probe.Runner
+	}
+)
+
+// NewWorker initializes and returns a new Worker.
+func NewWorker[In, Out any](cfg *WorkerConfig[In, Out]) *Worker[In, Out] {
+	poolCfg := cfg.getPool()
+	w := &Worker[In, Out]{
+		Pool:    newPool(&poolCfg),
+		process: cfg.Process,
+		results: make(chan Result[In, Out], cfg.getResultBufferSize()),
+	}
+	w.queue = &workerQueue[In]{
+		items: make(chan workerItem[In], poolCfg.getBufferSize()),
+		run:   w.run,
+	}
+	w.Pool.queue = w.queue
+	w.configure = func(_ int, probeCfg *probe.ProbeConfig) {
+		v := &workerView[In]{queue: w.queue}
+		v.runner = func() {
+			v.queue.run(v.in)
+		}
+		probeCfg.Queue = v
+	}
+	w.Start()
+	return w
+}
+
+// Submit queues an input for processing, blocking while the queue is full.
+func (w *Worker[In, Out]) Submit(in In) {
+	w.queue.items <- workerItem[In]{in: in}
+}
+
+// Feed submits every input received from inputs until inputs is closed or ctx is done. Feed returns the
+// error of ctx if it is done first.
+func (w *Worker[In, Out]) Feed(ctx context.Context, inputs <-chan In) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case in, ok := <-inputs:
+			if !ok {
+				return nil
+			}
+			w.Submit(in)
+		}
+	}
+}
+
+// Results returns the channel the results of all inputs are delivered on. Results must be received,
+// otherwise Probes block once the channel buffer is full. The channel is never closed.
+func (w *Worker[In, Out]) Results() <-chan Result[In, Out] {
+	return w.results
+}
+
+// run processes in and delivers its result.
+func (w *Worker[In, Out]) run(in In) {
+	out, err := w.call(in)
+	w.results <- Result[In, Out]{In: in, Out: out, Err: err}
+}
+
+// call calls the processing function, returning a *PanicError if it panics.
+func (w *Worker[In, Out]) call(in In) (out Out, err error) {
+	err = CallTask(w.context(), func(ctx context.Context) error {
+		out, err = w.process(ctx, in)
+		return err
+	})
+	return out, err
+}
+
+// Push implementation of probe.Queue for workerQueue.
+func (q *workerQueue[In]) Push(w probe.Work) {
+	q.items <- workerItem[In]{work: w}
+}
+
+// Pop implementation of probe.Queue for workerQueue. Probes of a Worker pop through their workerView.
+// Unlike workerView.Pop, Pop allocates a Runner for every input.
+func (q *workerQueue[In]) Pop(ctx context.Context) (probe.Work, bool) {
+	select {
+	case <-ctx.Done():
+		return probe.Work{}, false
+	case item := <-q.items:
+		if item.work.Runner != nil {
+			return item.work, true
+		}
+		return probe.Work{
+			Runner: func() {
+				q.run(item.in)
+			},
+		}, true
+	}
+}
+
+// Len implementation of probe.Queue for workerQueue.
+func (q *workerQueue[In]) Len() int {
+	return len(q.items)
+}
+
+// Push implementation of probe.Queue for workerView.
+func (v *workerView[In]) Push(w probe.Work) {
+	v.queue.Push(w)
+}
+
+// Pop implementation of probe.Queue for workerView. The returned Runner processes the input until the
+// next call to Pop.
+func (v *workerView[In]) Pop(ctx context.Context) (probe.Work, bool) {
+	select {
+	case <-ctx.Done():
+		return probe.Work{}, false
+	case item := <-v.queue.items:
+		if item.work.Runner != nil {
+			return item.work, true
+		}
+		v.in = item.in
+		return probe.Work{Runner: v.runner}, true
+	}
+}
+
+// Len implementation of probe.Queue for workerView.
+func (v *workerView[In]) Len() int {
+	return v.queue.Len()
+}
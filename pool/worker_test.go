@@ -0,0 +1,134 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/amplify-security/probe"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorker_Submit(t *testing.T) {
+	errOdd := errors.New("odd")
+	w := NewWorker(&WorkerConfig[int, string]{
+		Pool: &PoolConfig{
+			LogHandler: logHandler,
+			Size:       4,
+		},
+		Process: func(_ context.Context, in int) (string, error) {
+			switch {
+			case in == 7:
+				panic("seven")
+			case in%2 == 1:
+				return "", errOdd
+			default:
+				return strconv.Itoa(in), nil
+			}
+		},
+	})
+	go func() {
+		for i := range 10 {
+			w.Submit(i)
+		}
+	}()
+	outs := []string{}
+	errs := 0
+	for range 10 {
+		r := <-w.Results()
+		switch {
+		case r.In == 7:
+			var panicErr *PanicError
+			assert.ErrorAs(t, r.Err, &panicErr, "Process(panic) -> r.Err == *PanicError")
+		case r.In%2 == 1:
+			assert.ErrorIs(t, r.Err, errOdd, "Process(odd) -> r.Err == errOdd")
+			errs++
+		default:
+			assert.NoError(t, r.Err, "Process(even) -> r.Err == nil")
+			assert.Equal(t, strconv.Itoa(r.In), r.Out, "Process(even) -> r.Out == Itoa(r.In)")
+			outs = append(outs, r.Out)
+		}
+	}
+	slices.Sort(outs)
+	assert.Equal(t, []string{"0", "2", "4", "6", "8"}, outs, "Submit x10 -> even outputs")
+	assert.Equal(t, 4, errs, "Submit x10 -> odd errors")
+	done := make(chan struct{})
+	w.Run(func() {
+		close(done)
+	})
+	<-done
+	w.Stop(true)
+}
+
+func TestWorker_Feed(t *testing.T) {
+	w := NewWorker(&WorkerConfig[int, int]{
+		Pool: &PoolConfig{
+			LogHandler: logHandler,
+			Size:       2,
+		},
+		Process: func(_ context.Context, in int) (int, error) {
+			return in * in, nil
+		},
+		ResultBufferSize: 8,
+	})
+	inputs := make(chan int)
+	go func() {
+		for i := range 8 {
+			inputs <- i
+		}
+		close(inputs)
+	}()
+	assert.NoError(t, w.Feed(context.Background(), inputs), "Feed(closed inputs) -> nil")
+	sum := 0
+	for range 8 {
+		sum += (<-w.Results()).Out
+	}
+	assert.Equal(t, 140, sum, "Feed x8 -> sum of squares")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, w.Feed(ctx, make(chan int)), context.Canceled, "Feed(cancelled ctx) -> context.Canceled")
+	w.Stop(true)
+}
+
+func TestWorker_Allocs(t *testing.T) {
+	w := NewWorker(&WorkerConfig[int, int]{
+		Pool: &PoolConfig{
+			LogHandler: logHandler,
+			Size:       1,
+		},
+		Process: func(_ context.Context, in int) (int, error) {
+			return in, nil
+		},
+	})
+	allocs := testing.AllocsPerRun(100, func() {
+		w.Submit(1)
+		<-w.Results()
+	})
+	assert.Zero(t, allocs, "Submit + Results -> 0 allocs")
+	w.Stop(true)
+}
+
+func TestWorkerQueue_Pop(t *testing.T) {
+	var ran int
+	q := &workerQueue[int]{
+		items: make(chan workerItem[int], 2),
+		run: func(in int) {
+			ran = in
+		},
+	}
+	q.items <- workerItem[int]{in: 3}
+	q.Push(probe.Work{Name: "work", Runner: func() {}})
+	assert.Equal(t, 2, q.Len(), "Push x2 -> q.Len == 2")
+	w, ok := q.Pop(context.Background())
+	assert.True(t, ok, "Pop -> ok == true")
+	w.Runner()
+	assert.Equal(t, 3, ran, "Pop(input) -> run(input)")
+	w, _ = q.Pop(context.Background())
+	assert.Equal(t, "work", w.Name, "Pop(work) -> w.Name == work")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, ok = q.Pop(ctx)
+	assert.False(t, ok, "Pop(cancelled ctx) -> ok == false")
+}
@@ -2,10 +2,7 @@ package probe
 
 import (
 	"context"
-	"encoding/hex"
-	"io"
 	"log/slog"
-	"math/rand"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -15,56 +12,72 @@ type (
 	// Runner function type.
 	Runner func()
 
+	// InFlight describes the Work a Probe is currently executing.
+	InFlight struct {
+		ProbeID string            // ProbeID is the unique identifier of the Probe executing the Work.
+		Name    string            // Name is the name of the Work.
+		Labels  map[string]string // Labels are the labels of the Work.
+		Started time.Time         // Started is the time the Probe picked up the Work.
+		Elapsed time.Duration     // Elapsed is the time since the Probe picked up the Work.
+	}
+
+	// inFlight is the Work a Probe is currently executing.
+	inFlight struct {
+		mu     sync.Mutex
+		busy   bool
+		info   InFlight
+		cancel func()
+	}
+
 	// Probe is a helper that runs functions on a separate goroutine.
 	Probe struct {
 		log        *slog.Logger
 		ctx        context.Context
-		childCtx   context.Context
 		cancel     context.CancelFunc
-		work       chan Runner
+		queue      Queue
 		done       chan struct{}
-		running    *atomic.Bool
+		mu         sync.Mutex
+		state      State
 		runningCtr *atomic.Int32
 		idle       *atomic.Bool
 		idleCtr    *atomic.Int32
 		waitGroup  *sync.WaitGroup
+		onStart    func()
+		onStop     func()
 		id         string
+		name       string
+		register   bool
+		paused     bool
+		resumed    chan struct{}
+		pullCtx    context.Context
+		pullCancel context.CancelFunc
+		current    inFlight
+		goroutine  atomic.Uint64
 	}
 )
 
-var (
-	randStream io.Reader = rand.New(rand.NewSource(time.Now().UnixNano()))
-)
-
-// getID returns a random hex string to be used as a unique identifier for a Probe.
-func getID(log *slog.Logger) string {
-	buf := make([]byte, 6)
-	if _, err := randStream.Read(buf); err != nil {
-		log.Error("failed to generate probe ID", "error", err)
-		panic(err)
-	}
-	return hex.EncodeToString(buf)[:6]
-}
-
 // NewProbe initializes and returns a new Probe.
 func NewProbe(cfg *ProbeConfig) *Probe {
-	log := slog.New(cfg.getLogHandler())
-	id := getID(log)
-	ctxLogger := log.With("id", id, "source", "probe.Probe")
-	running := new(atomic.Bool)
-	running.Store(false)
+	id := cfg.getIDGenerator().NextID()
+	ctxLogger := slog.New(cfg.getLogHandler()).With("id", id, "source", "probe.Probe")
+	if cfg.Name != "" {
+		ctxLogger = ctxLogger.With("name", cfg.Name)
+	}
 	idle := new(atomic.Bool)
 	idle.Store(false)
 	p := &Probe{
 		log:        ctxLogger,
 		ctx:        cfg.getCtx(),
-		work:       cfg.getWorkChan(),
-		running:    running,
+		queue:      cfg.getQueue(),
 		runningCtr: cfg.getRunningCtr(),
 		idle:       idle,
 		idleCtr:    cfg.getIdleCtr(),
 		waitGroup:  cfg.getWaitGroup(),
+		onStart:    cfg.OnStart,
+		onStop:     cfg.OnStop,
 		id:         id,
+		name:       cfg.Name,
+		register:   cfg.Register,
 	}
 	p.Run()
 	return p
@@ -75,9 +88,23 @@ func (p *Probe) ID() string {
 	return p.id
 }
 
-// Running returns the status of the Probe: true if work event loop is running.
+// Name returns the configured name of the Probe.
+func (p *Probe) Name() string {
+	return p.name
+}
+
+// Running returns the status of the Probe: true if work event loop is running. A draining Probe is running
+// until it finishes its current work.
 func (p *Probe) Running() bool {
-	return p.running.Load()
+	s := p.State()
+	return s == StateRunning || s == StateDraining
+}
+
+// State returns the lifecycle state of the Probe.
+func (p *Probe) State() State {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.state
 }
 
 // Idle returns the status of the Probe: true if the Probe is Working but has no current work to execute.
@@ -85,56 +112,251 @@ func (p *Probe) Idle() bool {
 	return p.idle.Load()
 }
 
-// WorkChan returns the channel used for work events.
+// InFlight returns the Work the Probe is currently executing. InFlight returns false if the Probe is idle.
+func (p *Probe) InFlight() (InFlight, bool) {
+	p.current.mu.Lock()
+	defer p.current.mu.Unlock()
+	if !p.current.busy {
+		return InFlight{}, false
+	}
+	info := p.current.info
+	info.Elapsed = time.Since(info.Started)
+	return info, true
+}
+
+// CancelInFlight calls the Cancel function of the Work the Probe is currently executing. CancelInFlight
+// returns false if the Probe is idle or the Work has no Cancel function.
+func (p *Probe) CancelInFlight() bool {
+	p.current.mu.Lock()
+	cancel := p.current.cancel
+	p.current.mu.Unlock()
+	if cancel == nil {
+		return false
+	}
+	cancel()
+	return true
+}
+
+// Stack returns the stack trace of the goroutine running the event loop of the Probe, or nil if the event
+// loop is not running.
+func (p *Probe) Stack() []byte {
+	if !p.Running() {
+		return nil
+	}
+	return goroutineStack(p.goroutine.Load())
+}
+
+// WorkChan returns the channel used for work events. WorkChan returns nil if the Probe was configured
+// with a Queue that is not a ChanQueue.
 func (p *Probe) WorkChan() chan Runner {
-	return p.work
+	if q, ok := p.queue.(ChanQueue); ok {
+		return q
+	}
+	return nil
 }
 
-// Run is the main event loop for the Probe. Run will start a new goroutine.
-func (p *Probe) Run() {
-	if p.Running() {
-		return
+// Queue returns the Queue the Probe pulls work from.
+func (p *Probe) Queue() Queue {
+	return p.queue
+}
+
+// Run is the main event loop for the Probe. Run will start a new goroutine. Run returns ErrRunning if the
+// event loop is already running, or ErrDraining if the Probe is stopping but has not finished its current work.
+func (p *Probe) Run() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.run()
+}
+
+// RunContext is like Run, but first replaces the parent context of the Probe with ctx. This allows a Probe
+// whose parent context is done to be run again.
+func (p *Probe) RunContext(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.checkRun(); err != nil {
+		return err
+	}
+	p.ctx = ctx
+	return p.run()
+}
+
+// checkRun returns an error if the Probe cannot transition to StateRunning. The caller must hold p.mu.
+func (p *Probe) checkRun() error {
+	switch p.state {
+	case StateRunning:
+		return ErrRunning
+	case StateDraining:
+		return ErrDraining
+	default:
+		return nil
+	}
+}
+
+// run starts the event loop goroutine. The caller must hold p.mu.
+func (p *Probe) run() error {
+	if err := p.checkRun(); err != nil {
+		return err
 	}
 	// create a new cancelable child context only to be used by this goroutine
-	p.childCtx, p.cancel = context.WithCancel(p.ctx)
+	childCtx, cancel := context.WithCancel(p.ctx)
+	done := make(chan struct{})
+	p.cancel, p.done = cancel, done
+	p.state = StateRunning
+	p.idle.Store(true)
+	p.runningCtr.Add(1)
+	p.idleCtr.Add(1)
 	p.waitGroup.Add(1)
-	p.done = make(chan struct{})
+	if p.register && !probes.Register(p.name, p) {
+		p.log.Warn("failed to register probe, name is empty or already registered")
+	}
 	go func() {
 		p.log.Debug("starting event loop")
+		p.goroutine.Store(goroutineID())
 		defer p.waitGroup.Done()
-		p.running.Store(true)
-		p.idle.Store(true)
-		p.runningCtr.Add(1)
-		p.idleCtr.Add(1)
+		defer p.shutdown(done)
+		if p.onStop != nil {
+			defer p.onStop()
+		}
+		if p.onStart != nil {
+			p.onStart()
+		}
 		for {
-			select {
-			case <-p.childCtx.Done():
-				// the context is done, exit
-				p.log.Debug("shutting down")
-				p.running.Store(false)
-				p.idle.Store(true)
-				p.runningCtr.Add(-1)
-				close(p.done)
-				return
-			case runner := <-p.work:
-				p.idle.Store(false)
-				p.idleCtr.Add(-1)
-				runner()
-				p.idle.Store(true)
-				p.idleCtr.Add(1)
+			pullCtx, resumed := p.pullState(childCtx)
+			if resumed != nil {
+				// the probe is paused, wait until it is resumed or the context is done
+				select {
+				case <-childCtx.Done():
+					return
+				case <-resumed:
+					continue
+				}
+			}
+			work, ok := p.queue.Pop(pullCtx)
+			if !ok {
+				if childCtx.Err() != nil {
+					// the context is done, exit
+					return
+				}
+				// the probe was paused while waiting for work
+				continue
 			}
+			p.idle.Store(false)
+			p.idleCtr.Add(-1)
+			p.current.start(p.id, work)
+			work.Runner()
+			p.current.finish()
+			p.idle.Store(true)
+			p.idleCtr.Add(1)
 		}
 	}()
+	return nil
 }
 
-// Stop will stop the Probe from doing further work. Stop blocks if wait is true until current work is complete.
-func (p *Probe) Stop(wait bool) {
-	if !p.Running() {
+// start records work as in flight on the Probe with the given ID.
+func (f *inFlight) start(probeID string, work Work) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.busy = true
+	f.info = InFlight{
+		ProbeID: probeID,
+		Name:    work.Name,
+		Labels:  work.Labels,
+		Started: time.Now(),
+	}
+	f.cancel = work.Cancel
+}
+
+// finish clears the in flight work.
+func (f *inFlight) finish() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.busy = false
+	f.info = InFlight{}
+	f.cancel = nil
+}
+
+// shutdown moves the Probe to StateStopped when the event loop exits.
+func (p *Probe) shutdown(done chan struct{}) {
+	p.log.Debug("shutting down")
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.state = StateStopped
+	if p.register {
+		probes.Unregister(p.name, p)
+	}
+	p.runningCtr.Add(-1)
+	p.idleCtr.Add(-1)
+	close(done)
+}
+
+// pullState returns the context to pull work with. If the Probe is paused, pullState returns a channel that
+// is closed when the Probe is resumed instead.
+func (p *Probe) pullState(childCtx context.Context) (context.Context, chan struct{}) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.paused {
+		return nil, p.resumed
+	}
+	if p.pullCtx == nil || p.pullCtx.Err() != nil {
+		// pulls are canceled on Pause, so start a new pull context on the current event loop context
+		p.pullCtx, p.pullCancel = context.WithCancel(childCtx)
+	}
+	return p.pullCtx, nil
+}
+
+// Pause stops the Probe from pulling further work from its Queue, leaving the work queued. Work already
+// in progress runs to completion. The event loop keeps running and can be resumed with Resume.
+func (p *Probe) Pause() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.paused {
 		return
 	}
-	p.cancel()
-	if wait {
-		<-p.done
+	p.log.Debug("pausing")
+	p.paused = true
+	p.resumed = make(chan struct{})
+	if p.pullCancel != nil {
+		p.pullCancel()
+	}
+}
+
+// Resume resumes pulling work after Pause.
+func (p *Probe) Resume() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.paused {
 		return
 	}
+	p.log.Debug("resuming")
+	p.paused = false
+	close(p.resumed)
+}
+
+// Paused returns true if the Probe is paused.
+func (p *Probe) Paused() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.paused
+}
+
+// Stop will stop the Probe from doing further work. The Probe is draining until its current work is
+// complete. Stop blocks if wait is true until current work is complete. Stop returns ErrNotRunning if the
+// event loop is not running.
+func (p *Probe) Stop(wait bool) error {
+	p.mu.Lock()
+	switch p.state {
+	case StateRunning:
+		p.state = StateDraining
+		p.cancel()
+	case StateDraining:
+	default:
+		p.mu.Unlock()
+		return ErrNotRunning
+	}
+	done := p.done
+	p.mu.Unlock()
+	if wait {
+		<-done
+	}
+	return nil
 }
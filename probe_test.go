@@ -4,37 +4,36 @@ import (
 	"context"
 	"errors"
 	"log/slog"
-	"math/rand"
 	"os"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
-	"github.com/stretchr/testify/mock"
 )
 
 type (
-	// MockRandom is a mock Random stream for testing.
-	MockRandom struct {
-		mock.Mock
-	}
+	// MockQueue is a Queue that never has work, for testing.
+	MockQueue struct{}
 )
 
 var (
 	logHandler = slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug})
-	log        = slog.New(logHandler)
 )
 
-// Read implementation of io.Reader for MockRandom.
-func (m *MockRandom) Read(p []byte) (n int, err error) {
-	args := m.Called(p)
-	for _, i := range p {
-		p[i] = 0
-	}
-	if args.Get(1) != nil {
-		err = args.Error(1)
-	}
-	return args.Int(0), err
+// Push implementation of Queue for MockQueue.
+func (q *MockQueue) Push(_ Work) {}
+
+// Pop implementation of Queue for MockQueue.
+func (q *MockQueue) Pop(ctx context.Context) (Work, bool) {
+	<-ctx.Done()
+	return Work{}, false
+}
+
+// Len implementation of Queue for MockQueue.
+func (q *MockQueue) Len() int {
+	return 0
 }
 
 func waitForRunning(p *Probe) {
@@ -46,6 +45,15 @@ func waitForRunning(p *Probe) {
 	}
 }
 
+func waitForNotRunning(p *Probe) {
+	for i := 0; i < 10; i++ {
+		if !p.Running() {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
 func waitForIdle(p *Probe) {
 	for i := 0; i < 10; i++ {
 		// wait for goroutine to become idle after finishing work
@@ -74,7 +82,7 @@ func TestProbe_Working(t *testing.T) {
 	assert.True(t, test, "test == true")
 	p.Run()
 	cancel()
-	waitForIdle(p)
+	waitForNotRunning(p)
 	assert.False(t, p.Running(), "ctx cancel -> p.Running == false")
 }
 
@@ -108,6 +116,12 @@ func TestProbe_ID(t *testing.T) {
 		LogHandler: logHandler,
 	})
 	assert.NotEmpty(t, p.ID(), "p.ID -> !empty")
+	p = NewProbe(&ProbeConfig{
+		Ctx:         ctx,
+		LogHandler:  logHandler,
+		IDGenerator: NewSequenceIDGenerator("test"),
+	})
+	assert.Equal(t, "test-1", p.ID(), "NewProbe(IDGenerator) -> p.ID == test-1")
 }
 
 func TestProbe_Stop(t *testing.T) {
@@ -153,25 +167,254 @@ func TestProbe_Run(t *testing.T) {
 	p.Stop(true)
 }
 
-func TestGetID(t *testing.T) {
-	id := getID(log)
-	assert.NotEmpty(t, id, "id -> !empty")
-	m := &MockRandom{}
-	m.On("Read", mock.Anything).Return(3, nil)
-	randStream = m
-	id = getID(log)
-	assert.Equal(t, "000000", id, "id -> 000000")
-	m = &MockRandom{}
-	m.On("Read", mock.Anything).Return(0, errors.New("unexpected error"))
-	randStream = m
-	assert.Panics(t, func() {
-		id = getID(log)
-	}, "getID -> panic")
-	randStream = rand.New(rand.NewSource(time.Now().UnixNano()))
-}
-
-func BenchmarkGetID(b *testing.B) {
+func TestProbe_State(t *testing.T) {
+	ctrl := make(chan struct{})
+	started := make(chan struct{})
+	p := NewProbe(&ProbeConfig{
+		LogHandler: logHandler,
+	})
+	assert.Equal(t, StateRunning, p.State(), "NewProbe -> p.State == StateRunning")
+	assert.ErrorIs(t, p.Run(), ErrRunning, "Run(running) -> ErrRunning")
+	p.WorkChan() <- func() {
+		close(started)
+		<-ctrl
+	}
+	<-started
+	assert.NoError(t, p.Stop(false), "Stop(false) -> nil")
+	assert.Equal(t, StateDraining, p.State(), "Stop(false) + busy probe -> p.State == StateDraining")
+	assert.True(t, p.Running(), "Stop(false) + busy probe -> p.Running == true")
+	assert.ErrorIs(t, p.Run(), ErrDraining, "Run(draining) -> ErrDraining")
+	assert.ErrorIs(t, p.RunContext(context.Background()), ErrDraining, "RunContext(draining) -> ErrDraining")
+	assert.NoError(t, p.Stop(false), "Stop(draining) -> nil")
+	close(ctrl)
+	assert.NoError(t, p.Stop(true), "Stop(true) -> nil")
+	assert.Equal(t, StateStopped, p.State(), "Stop(true) -> p.State == StateStopped")
+	assert.ErrorIs(t, p.Stop(true), ErrNotRunning, "Stop(stopped) -> ErrNotRunning")
+	assert.NoError(t, p.Run(), "Run(stopped) -> nil")
+	assert.Equal(t, StateRunning, p.State(), "Run -> p.State == StateRunning")
+	assert.NoError(t, p.Stop(true), "Stop(true) -> nil")
+}
+
+func TestProbe_Concurrent(t *testing.T) {
+	ctr := new(atomic.Int32)
+	runningCtr := new(atomic.Int32)
+	// the probe may be stopped while work is submitted, so buffer all of it
+	work := make(chan Runner, 16*64)
+	p := NewProbe(&ProbeConfig{
+		LogHandler: logHandler,
+		WorkChan:   work,
+		RunningCtr: runningCtr,
+	})
+	wg := new(sync.WaitGroup)
+	for i := range 16 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range 64 {
+				var err error
+				switch (i + j) % 4 {
+				case 0:
+					err = p.Run()
+				case 1:
+					err = p.RunContext(context.Background())
+				case 2:
+					err = p.Stop(j%2 == 0)
+				case 3:
+					work <- func() {
+						ctr.Add(1)
+					}
+				}
+				if err != nil && !errors.Is(err, ErrRunning) && !errors.Is(err, ErrDraining) &&
+					!errors.Is(err, ErrNotRunning) {
+					t.Errorf("unexpected error: %v", err)
+				}
+				assert.LessOrEqual(t, runningCtr.Load(), int32(1), "concurrent Run -> runningCtr <= 1")
+			}
+		}()
+	}
+	wg.Wait()
+	p.Run()
+	for len(work) > 0 {
+		time.Sleep(time.Millisecond)
+	}
+	p.Stop(true)
+	assert.Equal(t, StateStopped, p.State(), "Stop(true) -> p.State == StateStopped")
+	assert.Equal(t, int32(0), runningCtr.Load(), "Stop(true) -> runningCtr == 0")
+	assert.Equal(t, int32(16*64/4), ctr.Load(), "concurrent work -> ctr == 256")
+}
+
+func TestProbe_RunContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	p := NewProbe(&ProbeConfig{
+		Ctx:        ctx,
+		LogHandler: logHandler,
+	})
+	waitForRunning(p)
+	cancel()
+	p.Stop(true)
+	p.Run()
+	<-p.done
+	assert.False(t, p.Running(), "Run(cancelled ctx) -> p.Running == false")
+	p.RunContext(context.Background())
+	waitForRunning(p)
+	assert.True(t, p.Running(), "RunContext(ctx) -> p.Running == true")
+	done := make(chan struct{})
+	p.WorkChan() <- func() {
+		close(done)
+	}
+	<-done
+	p.Stop(true)
+}
+
+func TestProbe_Pause(t *testing.T) {
+	ctr := new(atomic.Int32)
+	work := make(chan Runner, 2)
+	p := NewProbe(&ProbeConfig{
+		LogHandler: logHandler,
+		WorkChan:   work,
+	})
+	waitForRunning(p)
+	p.Pause()
+	p.Pause()
+	assert.True(t, p.Paused(), "Pause -> p.Paused == true")
+	work <- func() {
+		ctr.Add(1)
+	}
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, 1, len(work), "Pause -> work remains queued")
+	assert.True(t, p.Running(), "Pause -> p.Running == true")
+	p.Resume()
+	p.Resume()
+	assert.False(t, p.Paused(), "Resume -> p.Paused == false")
+	done := make(chan struct{})
+	work <- func() {
+		close(done)
+	}
+	<-done
+	assert.Equal(t, 1, int(ctr.Load()), "Resume -> queued work runs")
+	p.Pause()
+	p.Stop(true)
+	assert.False(t, p.Running(), "Pause + Stop -> p.Running == false")
+}
+
+func TestProbe_InFlight(t *testing.T) {
+	q := make(WorkChanQueue)
+	p := NewProbe(&ProbeConfig{
+		LogHandler: logHandler,
+		Queue:      q,
+	})
+	waitForRunning(p)
+	_, ok := p.InFlight()
+	assert.False(t, ok, "idle -> p.InFlight ok == false")
+	assert.False(t, p.CancelInFlight(), "idle -> p.CancelInFlight == false")
+	labels := map[string]string{"key": "value"}
+	started, ctrl, done := make(chan struct{}), make(chan struct{}), make(chan struct{})
+	q.Push(Work{
+		Runner: func() {
+			close(started)
+			<-ctrl
+		},
+		Name:   "test",
+		Labels: labels,
+		Cancel: func() {
+			close(ctrl)
+		},
+	})
+	<-started
+	time.Sleep(time.Millisecond)
+	inFlight, ok := p.InFlight()
+	assert.True(t, ok, "working -> p.InFlight ok == true")
+	assert.Equal(t, p.ID(), inFlight.ProbeID, "working -> inFlight.ProbeID == p.ID")
+	assert.Equal(t, "test", inFlight.Name, "working -> inFlight.Name == test")
+	assert.Equal(t, labels, inFlight.Labels, "working -> inFlight.Labels == labels")
+	assert.False(t, inFlight.Started.IsZero(), "working -> inFlight.Started != 0")
+	assert.GreaterOrEqual(t, inFlight.Elapsed, time.Millisecond, "working -> inFlight.Elapsed >= 1ms")
+	assert.Contains(t, string(p.Stack()), "TestProbe_InFlight", "working -> p.Stack contains Runner")
+	assert.True(t, p.CancelInFlight(), "working -> p.CancelInFlight == true")
+	q.Push(Work{
+		Runner: func() {
+			close(done)
+		},
+	})
+	<-done
+	waitForIdle(p)
+	_, ok = p.InFlight()
+	assert.False(t, ok, "finished -> p.InFlight ok == false")
+	p.Stop(true)
+	assert.Nil(t, p.Stack(), "Stop -> p.Stack == nil")
+}
+
+func TestProbe_Hooks(t *testing.T) {
+	starts, stops := new(atomic.Int32), new(atomic.Int32)
+	work := make(chan Runner)
+	p := NewProbe(&ProbeConfig{
+		LogHandler: logHandler,
+		WorkChan:   work,
+		OnStart: func() {
+			starts.Add(1)
+		},
+		OnStop: func() {
+			stops.Add(1)
+		},
+	})
+	done := make(chan struct{})
+	work <- func() {
+		close(done)
+	}
+	<-done
+	assert.Equal(t, 1, int(starts.Load()), "Run -> OnStart called")
+	assert.Equal(t, 0, int(stops.Load()), "Run -> OnStop not called")
+	p.Stop(true)
+	assert.Equal(t, 1, int(stops.Load()), "Stop -> OnStop called")
+	p.Run()
+	p.Stop(true)
+	assert.Equal(t, 2, int(starts.Load()), "Run + Stop -> OnStart called again")
+	assert.Equal(t, 2, int(stops.Load()), "Run + Stop -> OnStop called again")
+}
+
+func TestProbe_Queue(t *testing.T) {
+	work := make(chan Runner)
+	p := NewProbe(&ProbeConfig{
+		LogHandler: logHandler,
+		WorkChan:   work,
+	})
+	assert.Equal(t, work, p.WorkChan(), "NewProbe(WorkChan) -> p.WorkChan == work")
+	assert.Equal(t, ChanQueue(work), p.Queue(), "NewProbe(WorkChan) -> p.Queue == ChanQueue(work)")
+	waitForRunning(p)
+	p.Stop(true)
+	q := &MockQueue{}
+	p = NewProbe(&ProbeConfig{
+		LogHandler: logHandler,
+		Queue:      q,
+	})
+	assert.Nil(t, p.WorkChan(), "NewProbe(Queue) -> p.WorkChan == nil")
+	assert.Equal(t, q, p.Queue(), "NewProbe(Queue) -> p.Queue == q")
+	waitForRunning(p)
+	p.Stop(true)
+	assert.False(t, p.Running(), "p.Stop -> p.Running == false")
+}
+
+func TestSequenceIDGenerator(t *testing.T) {
+	g := NewSequenceIDGenerator("test")
+	assert.Equal(t, "test-1", g.NextID(), "NextID -> test-1")
+	assert.Equal(t, "test-2", g.NextID(), "NextID x2 -> test-2")
+	ids := new(sync.Map)
+	wg := new(sync.WaitGroup)
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 1000 {
+				_, loaded := ids.LoadOrStore(defaultIDGenerator.NextID(), struct{}{})
+				assert.False(t, loaded, "NextID(concurrent) -> unique")
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func BenchmarkSequenceIDGenerator(b *testing.B) {
 	for i := 0; i < b.N; i++ {
-		getID(log)
+		defaultIDGenerator.NextID()
 	}
 }
@@ -0,0 +1,74 @@
+package probe
+
+import (
+	"context"
+)
+
+type (
+	// Work is a Runner waiting in a Queue, with an optional name and labels that describe it while it is
+	// in flight.
+	Work struct {
+		Runner Runner            // Runner to execute.
+		Name   string            // Name of the work, reported by Probe.InFlight.
+		Labels map[string]string // Labels of the work, reported by Probe.InFlight.
+		Cancel func()            // Cancel is called by Probe.CancelInFlight to cancel the work. Optional.
+	}
+
+	// Queue is a source of work for a Probe.
+	Queue interface {
+		// Push adds Work to the Queue, blocking while the Queue is full.
+		Push(Work)
+		// Pop removes and returns the next Work, blocking until it is available.
+		// Pop returns false if ctx is done before Work is available.
+		Pop(ctx context.Context) (Work, bool)
+		// Len returns the number of Work items waiting in the Queue.
+		Len() int
+	}
+
+	// ChanQueue is a Queue backed by a channel of Runners. It is the default Queue for a Probe. A ChanQueue
+	// only holds the Runner of the Work pushed to it.
+	ChanQueue chan Runner
+
+	// WorkChanQueue is a Queue backed by a channel of Work.
+	WorkChanQueue chan Work
+)
+
+// Push implementation of Queue for ChanQueue.
+func (q ChanQueue) Push(w Work) {
+	q <- w.Runner
+}
+
+// Pop implementation of Queue for ChanQueue.
+func (q ChanQueue) Pop(ctx context.Context) (Work, bool) {
+	select {
+	case <-ctx.Done():
+		return Work{}, false
+	case r := <-q:
+		return Work{Runner: r}, true
+	}
+}
+
+// Len implementation of Queue for ChanQueue.
+func (q ChanQueue) Len() int {
+	return len(q)
+}
+
+// Push implementation of Queue for WorkChanQueue.
+func (q WorkChanQueue) Push(w Work) {
+	q <- w
+}
+
+// Pop implementation of Queue for WorkChanQueue.
+func (q WorkChanQueue) Pop(ctx context.Context) (Work, bool) {
+	select {
+	case <-ctx.Done():
+		return Work{}, false
+	case w := <-q:
+		return w, true
+	}
+}
+
+// Len implementation of Queue for WorkChanQueue.
+func (q WorkChanQueue) Len() int {
+	return len(q)
+}
@@ -0,0 +1,55 @@
+package probe
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChanQueue_PushPop(t *testing.T) {
+	var test bool
+	q := make(ChanQueue, 1)
+	q.Push(Work{
+		Runner: func() {
+			test = true
+		},
+		Name: "test",
+	})
+	assert.Equal(t, 1, q.Len(), "Push -> q.Len == 1")
+	w, ok := q.Pop(context.Background())
+	assert.True(t, ok, "Pop -> ok == true")
+	assert.Equal(t, 0, q.Len(), "Pop -> q.Len == 0")
+	assert.Empty(t, w.Name, "Pop -> w.Name == \"\"")
+	w.Runner()
+	assert.True(t, test, "w.Runner() -> test == true")
+}
+
+func TestChanQueue_Pop(t *testing.T) {
+	q := make(ChanQueue)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	w, ok := q.Pop(ctx)
+	assert.False(t, ok, "Pop(cancelled ctx) -> ok == false")
+	assert.Nil(t, w.Runner, "Pop(cancelled ctx) -> w.Runner == nil")
+}
+
+func TestWorkChanQueue_PushPop(t *testing.T) {
+	q := make(WorkChanQueue, 1)
+	labels := map[string]string{"key": "value"}
+	q.Push(Work{
+		Runner: func() {},
+		Name:   "test",
+		Labels: labels,
+	})
+	assert.Equal(t, 1, q.Len(), "Push -> q.Len == 1")
+	w, ok := q.Pop(context.Background())
+	assert.True(t, ok, "Pop -> ok == true")
+	assert.Equal(t, 0, q.Len(), "Pop -> q.Len == 0")
+	assert.Equal(t, "test", w.Name, "Pop -> w.Name == test")
+	assert.Equal(t, labels, w.Labels, "Pop -> w.Labels == labels")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, ok = q.Pop(ctx)
+	assert.False(t, ok, "Pop(cancelled ctx) -> ok == false")
+}
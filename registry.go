@@ -0,0 +1,79 @@
+package probe
+
+import (
+	"slices"
+	"sync"
+)
+
+type (
+	// Registry is a set of values indexed by unique name, safe for concurrent use.
+	Registry[T comparable] struct {
+		mu      sync.Mutex
+		entries map[string]T
+	}
+)
+
+var (
+	probes = new(Registry[*Probe]) // probes is the process-wide registry of Probes.
+)
+
+// Register adds v to the Registry under name. Register returns false if name is empty or already
+// registered to another value.
+func (r *Registry[T]) Register(name string, v T) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if name == "" {
+		return false
+	}
+	if current, ok := r.entries[name]; ok {
+		return current == v
+	}
+	if r.entries == nil {
+		r.entries = make(map[string]T)
+	}
+	r.entries[name] = v
+	return true
+}
+
+// Unregister removes v from the Registry if it is registered under name.
+func (r *Registry[T]) Unregister(name string, v T) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if current, ok := r.entries[name]; ok && current == v {
+		delete(r.entries, name)
+	}
+}
+
+// Lookup returns the value registered under name.
+func (r *Registry[T]) Lookup(name string) (T, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	v, ok := r.entries[name]
+	return v, ok
+}
+
+// All returns all registered values, ordered by name.
+func (r *Registry[T]) All() []T {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	names := make([]string, 0, len(r.entries))
+	for name := range r.entries {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	all := make([]T, 0, len(names))
+	for _, name := range names {
+		all = append(all, r.entries[name])
+	}
+	return all
+}
+
+// Lookup returns the registered running Probe with the given name.
+func Lookup(name string) (*Probe, bool) {
+	return probes.Lookup(name)
+}
+
+// Registered returns all registered running Probes, ordered by name.
+func Registered() []*Probe {
+	return probes.All()
+}
@@ -0,0 +1,63 @@
+package probe
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry(t *testing.T) {
+	r := new(Registry[*int])
+	a, b := new(int), new(int)
+	assert.False(t, r.Register("", a), "Register(empty name) -> false")
+	assert.True(t, r.Register("b", b), "Register(b) -> true")
+	assert.True(t, r.Register("a", a), "Register(a) -> true")
+	assert.True(t, r.Register("a", a), "Register(a, same value) -> true")
+	assert.False(t, r.Register("a", b), "Register(a, other value) -> false")
+	v, ok := r.Lookup("a")
+	assert.True(t, ok, "Lookup(a) -> ok == true")
+	assert.Same(t, a, v, "Lookup(a) -> a")
+	assert.Equal(t, []*int{a, b}, r.All(), "All -> ordered by name")
+	r.Unregister("a", b)
+	_, ok = r.Lookup("a")
+	assert.True(t, ok, "Unregister(a, other value) -> Lookup(a) ok == true")
+	r.Unregister("a", a)
+	_, ok = r.Lookup("a")
+	assert.False(t, ok, "Unregister(a) -> Lookup(a) ok == false")
+	assert.Equal(t, []*int{b}, r.All(), "Unregister(a) -> All == [b]")
+}
+
+func TestProbe_Register(t *testing.T) {
+	p := NewProbe(&ProbeConfig{
+		LogHandler: logHandler,
+		Name:       "registered",
+		Register:   true,
+	})
+	unregistered := NewProbe(&ProbeConfig{
+		LogHandler: logHandler,
+		Name:       "unregistered",
+	})
+	assert.Equal(t, "registered", p.Name(), "NewProbe -> p.Name == registered")
+	found, ok := Lookup("registered")
+	assert.True(t, ok, "Register -> Lookup ok == true")
+	assert.Same(t, p, found, "Register -> Lookup == p")
+	_, ok = Lookup("unregistered")
+	assert.False(t, ok, "!Register -> Lookup ok == false")
+	assert.Contains(t, Registered(), p, "Register -> Registered contains p")
+	duplicate := NewProbe(&ProbeConfig{
+		LogHandler: logHandler,
+		Name:       "registered",
+		Register:   true,
+	})
+	found, _ = Lookup("registered")
+	assert.Same(t, p, found, "Register(duplicate name) -> Lookup == p")
+	p.Stop(true)
+	_, ok = Lookup("registered")
+	assert.False(t, ok, "Stop -> Lookup ok == false")
+	p.Run()
+	_, ok = Lookup("registered")
+	assert.True(t, ok, "Run -> Lookup ok == true")
+	p.Stop(true)
+	duplicate.Stop(true)
+	unregistered.Stop(true)
+}
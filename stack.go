@@ -0,0 +1,42 @@
+package probe
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"strconv"
+)
+
+// goroutineID returns the ID of the calling goroutine, parsed from the header of its stack trace.
+func goroutineID() uint64 {
+	buf := make([]byte, 64)
+	buf = buf[:runtime.Stack(buf, false)]
+	// the header has the form "goroutine 123 [running]:"
+	buf = bytes.TrimPrefix(buf, []byte("goroutine "))
+	end := bytes.IndexByte(buf, ' ')
+	if end < 0 {
+		return 0
+	}
+	id, _ := strconv.ParseUint(string(buf[:end]), 10, 64)
+	return id
+}
+
+// goroutineStack returns the stack trace of the goroutine with the given ID, or nil if it does not exist.
+func goroutineStack(id uint64) []byte {
+	buf := make([]byte, 64<<10)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+	header := []byte(fmt.Sprintf("goroutine %d [", id))
+	for _, stack := range bytes.Split(buf, []byte("\n\n")) {
+		if bytes.HasPrefix(stack, header) {
+			return stack
+		}
+	}
+	return nil
+}
@@ -0,0 +1,21 @@
+package probe
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGoroutineStack(t *testing.T) {
+	id := make(chan uint64)
+	ctrl := make(chan struct{})
+	go func() {
+		id <- goroutineID()
+		<-ctrl
+	}()
+	stack := goroutineStack(<-id)
+	assert.Contains(t, string(stack), "TestGoroutineStack", "goroutineStack(id) -> contains creator")
+	close(ctrl)
+	assert.NotZero(t, goroutineID(), "goroutineID -> != 0")
+	assert.Nil(t, goroutineStack(0), "goroutineStack(0) -> nil")
+}
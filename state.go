@@ -0,0 +1,39 @@
+package probe
+
+import (
+	"errors"
+)
+
+const (
+	StateCreated  State = iota // StateCreated has not been started yet.
+	StateRunning               // StateRunning is running its event loop.
+	StateDraining              // StateDraining was stopped and is finishing its current work.
+	StateStopped               // StateStopped was stopped and has no running event loop. It can be run again.
+)
+
+type (
+	// State is the lifecycle state of a Probe or Pool.
+	State int
+)
+
+var (
+	ErrRunning    = errors.New("probe: already running") // ErrRunning is returned when starting a running Probe.
+	ErrDraining   = errors.New("probe: draining")        // ErrDraining is returned when starting a draining Probe.
+	ErrNotRunning = errors.New("probe: not running")     // ErrNotRunning is returned when stopping a stopped Probe.
+)
+
+// String implementation of fmt.Stringer for State.
+func (s State) String() string {
+	switch s {
+	case StateCreated:
+		return "created"
+	case StateRunning:
+		return "running"
+	case StateDraining:
+		return "draining"
+	case StateStopped:
+		return "stopped"
+	default:
+		return "unknown"
+	}
+}
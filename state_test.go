@@ -0,0 +1,44 @@
+package probe
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestState_String(t *testing.T) {
+	cases := []struct {
+		s   State
+		e   string
+		msg string
+	}{
+		{
+			s:   StateCreated,
+			e:   "created",
+			msg: "StateCreated.String -> created",
+		},
+		{
+			s:   StateRunning,
+			e:   "running",
+			msg: "StateRunning.String -> running",
+		},
+		{
+			s:   StateDraining,
+			e:   "draining",
+			msg: "StateDraining.String -> draining",
+		},
+		{
+			s:   StateStopped,
+			e:   "stopped",
+			msg: "StateStopped.String -> stopped",
+		},
+		{
+			s:   State(-1),
+			e:   "unknown",
+			msg: "State(-1).String -> unknown",
+		},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.e, c.s.String(), c.msg)
+	}
+}
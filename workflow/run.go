@@ -0,0 +1,215 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/amplify-security/probe/pool"
+)
+
+const (
+	StatusPending   Status = iota // StatusPending is a task that was never submitted.
+	StatusDone                    // StatusDone is a task that returned without error.
+	StatusFailed                  // StatusFailed is a task that returned an error or panicked.
+	StatusSkipped                 // StatusSkipped is a task that was not run because a dependency did not finish.
+	StatusCancelled               // StatusCancelled is a task that was cancelled because the Run context is done.
+)
+
+type (
+	// Status is the final status of a task in a Report.
+	Status int
+
+	// TaskReport is the outcome of a single task of a Workflow run.
+	TaskReport struct {
+		Name     string    // Name is the name of the task.
+		Status   Status    // Status is the final status of the task.
+		Output   any       // Output is the output of a done task.
+		Err      error     // Err is the error of a failed or cancelled task. Panics are reported as a *pool.PanicError.
+		Cause    string    // Cause is the name of the failed or cancelled task a skipped task was skipped for.
+		Started  time.Time // Started is the time a Probe started the task, or the zero time if it never started.
+		Finished time.Time // Finished is the time the task finished, or the zero time if it never finished.
+	}
+
+	// Report is the outcome of a Workflow run.
+	Report struct {
+		Workflow string       // Workflow is the name of the Workflow.
+		Tasks    []TaskReport // Tasks are the outcomes of all tasks in the order they were added.
+		Started  time.Time    // Started is the time the run started.
+		Finished time.Time    // Finished is the time the run finished.
+	}
+
+	// TaskError is the error of a failed task.
+	TaskError struct {
+		Task string // Task is the name of the failed task.
+		Err  error  // Err is the error of the task.
+	}
+
+	// result is a finished task submitted to the Pool.
+	result struct {
+		i int
+		h *pool.TaskHandle
+	}
+)
+
+// String implementation of fmt.Stringer for Status.
+func (s Status) String() string {
+	switch s {
+	case StatusPending:
+		return "pending"
+	case StatusDone:
+		return "done"
+	case StatusFailed:
+		return "failed"
+	case StatusSkipped:
+		return "skipped"
+	case StatusCancelled:
+		return "cancelled"
+	default:
+		return "unknown"
+	}
+}
+
+// Error implementation of error for TaskError.
+func (e *TaskError) Error() string {
+	return fmt.Sprintf("workflow: task %q failed: %v", e.Task, e.Err)
+}
+
+// Unwrap returns the error of the task.
+func (e *TaskError) Unwrap() error {
+	return e.Err
+}
+
+// Task returns the TaskReport of the task with the given name. Task returns false if there is no such task.
+func (r *Report) Task(name string) (TaskReport, bool) {
+	for _, t := range r.Tasks {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return TaskReport{}, false
+}
+
+// Status returns the TaskReports of all tasks with the given status.
+func (r *Report) Status(status Status) []TaskReport {
+	var tasks []TaskReport
+	for _, t := range r.Tasks {
+		if t.Status == status {
+			tasks = append(tasks, t)
+		}
+	}
+	return tasks
+}
+
+// Err returns a *TaskError for every failed task joined with errors.Join, or nil if no task failed.
+func (r *Report) Err() error {
+	var errs []error
+	for _, t := range r.Status(StatusFailed) {
+		errs = append(errs, &TaskError{Task: t.Name, Err: t.Err})
+	}
+	return errors.Join(errs...)
+}
+
+// Run runs the Workflow on p and returns a Report of all tasks. A task is submitted to p once all of its
+// dependencies are done, and receives their outputs as inputs. If a task fails, all tasks that depend on
+// it, directly or indirectly, are skipped while independent tasks keep running. If ctx is done, running
+// tasks are cancelled and no further tasks are submitted. Run returns the error of ctx if it is done,
+// otherwise the error of the Report.
+func (w *Workflow) Run(ctx context.Context, p *pool.Pool) (*Report, error) {
+	report := &Report{
+		Workflow: w.name,
+		Tasks:    make([]TaskReport, len(w.tasks)),
+		Started:  time.Now(),
+	}
+	index := make(map[*task]int, len(w.tasks))
+	waiting := make([]int, len(w.tasks))
+	outputs := make([]any, len(w.tasks))
+	for i, t := range w.tasks {
+		report.Tasks[i] = TaskReport{Name: t.name, Status: StatusPending}
+		index[t] = i
+		waiting[i] = len(t.deps)
+	}
+	var labels map[string]string
+	if w.name != "" {
+		labels = map[string]string{"workflow": w.name}
+	}
+	results := make(chan result, len(w.tasks))
+	running := map[int]*pool.TaskHandle{}
+	submit := func(i int) {
+		t := w.tasks[i]
+		inputs := make(map[string]any, len(t.parents))
+		for _, parent := range t.parents {
+			inputs[parent.name] = outputs[index[parent]]
+		}
+		h := p.SubmitNamed(t.name, labels, func(ctx context.Context) error {
+			// the output is read once the handle is done, which happens after the task returns
+			out, err := t.fn(ctx, inputs)
+			outputs[i] = out
+			return err
+		})
+		running[i] = h
+		go func() {
+			<-h.Done()
+			results <- result{i: i, h: h}
+		}()
+	}
+	for i := range w.tasks {
+		if waiting[i] == 0 && ctx.Err() == nil {
+			submit(i)
+		}
+	}
+	done := ctx.Done()
+	for len(running) > 0 {
+		select {
+		case <-done:
+			for _, h := range running {
+				h.Cancel()
+			}
+			done = nil
+		case r := <-results:
+			delete(running, r.i)
+			tr := &report.Tasks[r.i]
+			tr.Err, tr.Started, tr.Finished = r.h.Err(), r.h.Started(), r.h.Finished()
+			switch r.h.Status() {
+			case pool.TaskDone:
+				tr.Status, tr.Output = StatusDone, outputs[r.i]
+				for _, child := range w.tasks[r.i].children {
+					c := index[child]
+					waiting[c]--
+					if waiting[c] == 0 && report.Tasks[c].Status == StatusPending && ctx.Err() == nil {
+						submit(c)
+					}
+				}
+				continue
+			case pool.TaskCancelled:
+				tr.Status = StatusCancelled
+			default:
+				tr.Status = StatusFailed
+			}
+			w.skip(report, index, w.tasks[r.i], w.tasks[r.i].name)
+		}
+	}
+	for i := range report.Tasks {
+		if report.Tasks[i].Status == StatusPending && ctx.Err() != nil {
+			report.Tasks[i].Status, report.Tasks[i].Err = StatusCancelled, ctx.Err()
+		}
+	}
+	report.Finished = time.Now()
+	if err := ctx.Err(); err != nil {
+		return report, err
+	}
+	return report, report.Err()
+}
+
+// skip marks all pending tasks that depend on t, directly or indirectly, as skipped for cause.
+func (w *Workflow) skip(report *Report, index map[*task]int, t *task, cause string) {
+	for _, child := range t.children {
+		tr := &report.Tasks[index[child]]
+		if tr.Status != StatusPending {
+			continue
+		}
+		tr.Status, tr.Cause = StatusSkipped, cause
+		w.skip(report, index, child, cause)
+	}
+}
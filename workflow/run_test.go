@@ -0,0 +1,161 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/amplify-security/probe/logging"
+	"github.com/amplify-security/probe/pool"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	logHandler = &logging.NoopLogHandler{}
+)
+
+func value(v int) TaskFunc {
+	return func(_ context.Context, _ map[string]any) (any, error) {
+		return v, nil
+	}
+}
+
+func sum(_ context.Context, inputs map[string]any) (any, error) {
+	total := 0
+	for _, v := range inputs {
+		total += v.(int)
+	}
+	return total, nil
+}
+
+func TestWorkflow_Run(t *testing.T) {
+	p := pool.NewPool(&pool.PoolConfig{LogHandler: logHandler, Size: 4})
+	defer p.Stop(true)
+	inFlight, maxInFlight := new(atomic.Int32), new(atomic.Int32)
+	parallel := func(v int) TaskFunc {
+		return func(_ context.Context, _ map[string]any) (any, error) {
+			n := inFlight.Add(1)
+			for {
+				m := maxInFlight.Load()
+				if n <= m || maxInFlight.CompareAndSwap(m, n) {
+					break
+				}
+			}
+			time.Sleep(20 * time.Millisecond)
+			inFlight.Add(-1)
+			return v, nil
+		}
+	}
+	w, err := NewBuilder("run").
+		Add("a", parallel(1)).
+		Add("b", parallel(2)).
+		Add("c", sum, "a", "b").
+		Add("d", sum, "c").
+		Build()
+	assert.NoError(t, err, "Builder.Build -> err == nil")
+	report, err := w.Run(context.Background(), p)
+	assert.NoError(t, err, "Workflow.Run -> err == nil")
+	assert.Equal(t, int32(2), maxInFlight.Load(), "Workflow.Run -> independent tasks run in parallel")
+	assert.Equal(t, "run", report.Workflow, "Report.Workflow -> run")
+	for _, tr := range report.Tasks {
+		assert.Equal(t, StatusDone, tr.Status, "TaskReport.Status -> done")
+		assert.False(t, tr.Started.IsZero(), "TaskReport.Started -> set")
+		assert.False(t, tr.Finished.Before(tr.Started), "TaskReport.Finished -> after Started")
+	}
+	c, _ := report.Task("c")
+	d, ok := report.Task("d")
+	assert.True(t, ok, "Report.Task(d) -> found")
+	assert.Equal(t, 3, c.Output, "Report.Task(c).Output -> a + b")
+	assert.Equal(t, 3, d.Output, "Report.Task(d).Output -> c")
+	assert.False(t, c.Started.Before(report.Tasks[0].Finished), "Report.Task(c) -> started after a finished")
+	_, ok = report.Task("e")
+	assert.False(t, ok, "Report.Task(e) -> not found")
+}
+
+func TestWorkflow_RunFailure(t *testing.T) {
+	p := pool.NewPool(&pool.PoolConfig{LogHandler: logHandler, Size: 2})
+	defer p.Stop(true)
+	errBoom := errors.New("boom")
+	w, err := NewBuilder("").
+		Add("a", value(1)).
+		Add("b", func(_ context.Context, _ map[string]any) (any, error) {
+			return nil, errBoom
+		}, "a").
+		Add("c", sum, "a", "b").
+		Add("d", sum, "c").
+		Add("e", sum, "a").
+		Add("f", func(_ context.Context, _ map[string]any) (any, error) {
+			panic("boom")
+		}).
+		Build()
+	assert.NoError(t, err, "Builder.Build -> err == nil")
+	report, err := w.Run(context.Background(), p)
+	assert.ErrorIs(t, err, errBoom, "Workflow.Run(failure) -> err wraps task error")
+	var taskErr *TaskError
+	assert.ErrorAs(t, err, &taskErr, "Workflow.Run(failure) -> err is *TaskError")
+	var panicErr *pool.PanicError
+	assert.ErrorAs(t, err, &panicErr, "Workflow.Run(panic) -> err is *pool.PanicError")
+	expected := map[string]Status{
+		"a": StatusDone,
+		"b": StatusFailed,
+		"c": StatusSkipped,
+		"d": StatusSkipped,
+		"e": StatusDone,
+		"f": StatusFailed,
+	}
+	for name, status := range expected {
+		tr, _ := report.Task(name)
+		assert.Equal(t, status, tr.Status, "Report.Task("+name+").Status -> "+status.String())
+	}
+	for _, tr := range report.Status(StatusSkipped) {
+		assert.Equal(t, "b", tr.Cause, "Report.Task("+tr.Name+").Cause -> b")
+		assert.True(t, tr.Started.IsZero(), "Report.Task("+tr.Name+") -> never started")
+	}
+	assert.Len(t, report.Status(StatusFailed), 2, "Report.Status(failed) -> 2 tasks")
+}
+
+func TestWorkflow_RunCancel(t *testing.T) {
+	p := pool.NewPool(&pool.PoolConfig{LogHandler: logHandler, Size: 2})
+	defer p.Stop(true)
+	started := make(chan struct{})
+	w, err := NewBuilder("").
+		Add("a", func(ctx context.Context, _ map[string]any) (any, error) {
+			close(started)
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}).
+		Add("b", sum, "a").
+		Build()
+	assert.NoError(t, err, "Builder.Build -> err == nil")
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-started
+		cancel()
+	}()
+	report, err := w.Run(ctx, p)
+	assert.ErrorIs(t, err, context.Canceled, "Workflow.Run(cancel) -> err == context.Canceled")
+	a, _ := report.Task("a")
+	b, _ := report.Task("b")
+	assert.Equal(t, StatusCancelled, a.Status, "Report.Task(a).Status -> cancelled")
+	assert.Equal(t, StatusSkipped, b.Status, "Report.Task(b).Status -> skipped")
+	assert.Equal(t, "a", b.Cause, "Report.Task(b).Cause -> a")
+}
+
+func TestStatus_String(t *testing.T) {
+	cases := []struct {
+		status   Status
+		expected string
+	}{
+		{StatusPending, "pending"},
+		{StatusDone, "done"},
+		{StatusFailed, "failed"},
+		{StatusSkipped, "skipped"},
+		{StatusCancelled, "cancelled"},
+		{Status(-1), "unknown"},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.expected, c.status.String(), "Status.String -> "+c.expected)
+	}
+}
@@ -0,0 +1,179 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+type (
+	// TaskFunc is the function of a workflow task. inputs holds the outputs of the dependencies of the task,
+	// keyed by task name.
+	TaskFunc func(ctx context.Context, inputs map[string]any) (any, error)
+
+	// Builder declares the tasks of a Workflow and their dependencies.
+	Builder struct {
+		name  string
+		tasks []*task
+		index map[string]*task
+		err   error
+	}
+
+	// Workflow is a validated directed acyclic graph of tasks that can be run on a Pool.
+	Workflow struct {
+		name  string
+		tasks []*task
+	}
+
+	// task is a node of a Workflow.
+	task struct {
+		name     string
+		fn       TaskFunc
+		deps     []string
+		parents  []*task
+		children []*task
+	}
+
+	// CycleError is the error of a Workflow whose dependencies form a cycle.
+	CycleError struct {
+		Path []string // Path is the cycle, each task depending on the next, starting and ending with the same task.
+	}
+)
+
+var (
+	ErrEmptyName         = errors.New("workflow: empty task name")    // ErrEmptyName is returned when a task has no name.
+	ErrDuplicateTask     = errors.New("workflow: duplicate task")     // ErrDuplicateTask is returned when a task name is added twice.
+	ErrUnknownDependency = errors.New("workflow: unknown dependency") // ErrUnknownDependency is returned when a dependency was never added.
+)
+
+// Error implementation of error for CycleError.
+func (e *CycleError) Error() string {
+	return "workflow: dependency cycle: " + strings.Join(e.Path, " -> ")
+}
+
+// NewBuilder initializes and returns a new Builder for a Workflow with the given name. The name is attached
+// as the "workflow" label to all tasks submitted to the Pool. name may be empty.
+func NewBuilder(name string) *Builder {
+	return &Builder{
+		name:  name,
+		index: map[string]*task{},
+	}
+}
+
+// Add adds a task that runs fn once all tasks named in deps have finished successfully. Dependencies may
+// be added after the task that depends on them. Errors are reported by Build.
+func (b *Builder) Add(name string, fn TaskFunc, deps ...string) *Builder {
+	if b.err != nil {
+		return b
+	}
+	switch {
+	case name == "":
+		b.err = ErrEmptyName
+	case b.index[name] != nil:
+		b.err = fmt.Errorf("%w: %q", ErrDuplicateTask, name)
+	default:
+		t := &task{
+			name: name,
+			fn:   fn,
+			deps: deps,
+		}
+		b.tasks = append(b.tasks, t)
+		b.index[name] = t
+	}
+	return b
+}
+
+// Build validates the tasks and returns the Workflow. Build returns an error if a task was added with an
+// empty or duplicate name, depends on a task that was never added, or if the dependencies form a cycle,
+// in which case the error is a *CycleError.
+func (b *Builder) Build() (*Workflow, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	// the Workflow gets its own copy of the tasks, so the Builder can be reused
+	tasks := make([]*task, len(b.tasks))
+	index := make(map[string]*task, len(b.tasks))
+	for i, t := range b.tasks {
+		tasks[i] = &task{
+			name: t.name,
+			fn:   t.fn,
+			deps: t.deps,
+		}
+		index[t.name] = tasks[i]
+	}
+	for _, t := range tasks {
+		for _, dep := range t.deps {
+			parent := index[dep]
+			if parent == nil {
+				return nil, fmt.Errorf("%w: %q depends on %q", ErrUnknownDependency, t.name, dep)
+			}
+			t.parents = append(t.parents, parent)
+			parent.children = append(parent.children, t)
+		}
+	}
+	if path := b.cycle(); path != nil {
+		return nil, &CycleError{Path: path}
+	}
+	return &Workflow{
+		name:  b.name,
+		tasks: tasks,
+	}, nil
+}
+
+// cycle returns the first dependency cycle found by a depth first search, or nil if there is none.
+func (b *Builder) cycle() []string {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[*task]int, len(b.tasks))
+	var path []string
+	var visit func(t *task) []string
+	visit = func(t *task) []string {
+		state[t] = visiting
+		path = append(path, t.name)
+		for _, dep := range t.deps {
+			parent := b.index[dep]
+			switch state[parent] {
+			case visiting:
+				// the cycle starts where the parent was first visited on the current path
+				for i, name := range path {
+					if name == parent.name {
+						return append(append([]string{}, path[i:]...), parent.name)
+					}
+				}
+			case unvisited:
+				if cycle := visit(parent); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[t] = visited
+		return nil
+	}
+	for _, t := range b.tasks {
+		if state[t] == unvisited {
+			if cycle := visit(t); cycle != nil {
+				return cycle
+			}
+		}
+	}
+	return nil
+}
+
+// Name returns the name of the Workflow.
+func (w *Workflow) Name() string {
+	return w.name
+}
+
+// Tasks returns the names of the tasks of the Workflow in the order they were added.
+func (w *Workflow) Tasks() []string {
+	names := make([]string, len(w.tasks))
+	for i, t := range w.tasks {
+		names[i] = t.name
+	}
+	return names
+}
@@ -0,0 +1,68 @@
+package workflow
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func noop(_ context.Context, _ map[string]any) (any, error) {
+	return nil, nil
+}
+
+func TestBuilder_Build(t *testing.T) {
+	w, err := NewBuilder("build").
+		Add("d", noop, "c").
+		Add("a", noop).
+		Add("b", noop).
+		Add("c", noop, "a", "b").
+		Build()
+	assert.NoError(t, err, "Builder.Build -> err == nil")
+	assert.Equal(t, "build", w.Name(), "Workflow.Name -> build")
+	assert.Equal(t, []string{"d", "a", "b", "c"}, w.Tasks(), "Workflow.Tasks -> tasks in added order")
+	cases := []struct {
+		b        *Builder
+		expected error
+	}{
+		{NewBuilder("").Add("", noop), ErrEmptyName},
+		{NewBuilder("").Add("a", noop).Add("a", noop), ErrDuplicateTask},
+		{NewBuilder("").Add("a", noop, "b"), ErrUnknownDependency},
+	}
+	for _, c := range cases {
+		_, err := c.b.Build()
+		assert.ErrorIs(t, err, c.expected, "Builder.Build -> expected error")
+	}
+}
+
+func TestBuilder_BuildCycle(t *testing.T) {
+	cases := []struct {
+		b        *Builder
+		expected []string
+	}{
+		{NewBuilder("").Add("a", noop, "a"), []string{"a", "a"}},
+		{NewBuilder("").Add("a", noop, "b").Add("b", noop, "a"), []string{"a", "b", "a"}},
+		{NewBuilder("").Add("a", noop).Add("b", noop, "a", "d").Add("c", noop, "b").Add("d", noop, "c"),
+			[]string{"b", "d", "c", "b"}},
+	}
+	for _, c := range cases {
+		_, err := c.b.Build()
+		var cycleErr *CycleError
+		if assert.ErrorAs(t, err, &cycleErr, "Builder.Build(cycle) -> err is *CycleError") {
+			assert.Equal(t, c.expected, cycleErr.Path, "CycleError.Path -> expected cycle")
+		}
+	}
+	_, err := NewBuilder("").Add("a", noop, "b").Add("b", noop, "a").Build()
+	assert.EqualError(t, err, "workflow: dependency cycle: a -> b -> a", "CycleError.Error -> expected message")
+}
+
+func TestBuilder_Reuse(t *testing.T) {
+	b := NewBuilder("").Add("a", noop)
+	w, err := b.Build()
+	assert.NoError(t, err, "Builder.Build -> err == nil")
+	b.Add("b", noop, "a")
+	_, err = b.Build()
+	assert.NoError(t, err, "Builder.Build(reused) -> err == nil")
+	assert.Equal(t, []string{"a"}, w.Tasks(), "Workflow.Tasks -> unchanged by later Add")
+	assert.Empty(t, w.tasks[0].children, "Workflow -> unchanged by later Build")
+}